@@ -0,0 +1,190 @@
+// Command morphy provides dictionary maintenance tools.
+//
+// Usage:
+//
+//	morphy estimate-cpd -dict <path> -corpus <annot.opcorpora.xml> [-min-word-freq N]
+//	morphy diff [-json] [-limit N] <old dict> <new dict>
+//	morphy validate <dict>...
+//	morphy export -dict <path> [-format tsv|unimorph] [-pos NOUN,VERB] [-grammemes sing,nomn] [-o file]
+//	morphy import-hunspell -dic <file.dic> -aff <file.aff> -out <path> [-rules rules.json] [-lang code] [-reproducible]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"morphy/pkg/analyzer"
+	"morphy/pkg/dict"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"estimate-cpd", "estimate P(t|w) from annotated OpenCorpora corpus", estimateCPD},
+	{"diff", "compare two compiled dictionaries", diffDicts},
+	{"validate", "check integrity of compiled dictionaries", validateDicts},
+	{"export", "export dictionary words as TSV or UniMorph lexicon", exportDict},
+	{"import-hunspell", "compile a dictionary from Hunspell .dic/.aff files", importHunspell},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "morphy %s: %v\n", c.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: morphy <command> [options]")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", c.name, c.usage)
+	}
+}
+
+func estimateCPD(args []string) error {
+	fs := flag.NewFlagSet("estimate-cpd", flag.ExitOnError)
+	dictPath := fs.String("dict", "", "dictionary directory to update")
+	corpus := fs.String("corpus", "", "annotated corpus (annot.opcorpora.xml)")
+	minWordFreq := fs.Int("min-word-freq", 1, "skip words seen less often")
+	fs.Parse(args)
+	if *dictPath == "" || *corpus == "" {
+		fs.Usage()
+		return fmt.Errorf("-dict and -corpus are required")
+	}
+	return analyzer.AddConditionalTagProbability(*corpus, *dictPath, *minWordFreq)
+}
+
+func diffDicts(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the diff as JSON")
+	limit := fs.Int("limit", 20, "examples per section in the summary, -1 for all")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return fmt.Errorf("two dictionary directories are required")
+	}
+	diff, err := dict.DiffDictionaries(fs.Arg(0), fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diff)
+	}
+	return diff.WriteSummary(os.Stdout, *limit)
+}
+
+func validateDicts(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("dictionary directory is required")
+	}
+	failed := 0
+	for _, path := range fs.Args() {
+		if err := dict.ValidateDict(path); err != nil {
+			fmt.Printf("%s: FAIL\n%v\n", path, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s: ok\n", path)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d dictionaries are invalid", failed, fs.NArg())
+	}
+	return nil
+}
+
+func exportDict(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	dictPath := fs.String("dict", "", "dictionary directory")
+	format := fs.String("format", dict.ExportTSV, "output format: tsv or unimorph")
+	pos := fs.String("pos", "", "comma-separated parts of speech to export")
+	grammemes := fs.String("grammemes", "", "comma-separated grammemes required in tags")
+	out := fs.String("o", "", "output file (default stdout)")
+	fs.Parse(args)
+	if *dictPath == "" {
+		fs.Usage()
+		return fmt.Errorf("-dict is required")
+	}
+	d, err := dict.NewDictionary(*dictPath)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	w := os.Stdout
+	if *out != "" {
+		if w, err = os.Create(*out); err != nil {
+			return err
+		}
+	}
+	opts := dict.ExportOptions{Format: *format, POS: splitList(*pos), Grammemes: splitList(*grammemes)}
+	if err := dict.Export(w, d, opts); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func importHunspell(args []string) error {
+	fs := flag.NewFlagSet("import-hunspell", flag.ExitOnError)
+	dicPath := fs.String("dic", "", "Hunspell .dic file")
+	affPath := fs.String("aff", "", "Hunspell .aff file")
+	outPath := fs.String("out", "", "output dictionary directory")
+	rules := fs.String("rules", "", "JSON file with grammeme rules (dict.HunspellOptions)")
+	lang := fs.String("lang", "", "language code stored in meta")
+	reproducible := fs.Bool("reproducible", false, "do not record compile time, so identical inputs give identical files")
+	fs.Parse(args)
+	if *dicPath == "" || *affPath == "" || *outPath == "" {
+		fs.Usage()
+		return fmt.Errorf("-dic, -aff and -out are required")
+	}
+	var opts dict.HunspellOptions
+	if *rules != "" {
+		b, err := os.ReadFile(*rules)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(b, &opts); err != nil {
+			return fmt.Errorf("%s: %w", *rules, err)
+		}
+	}
+	parsed, err := dict.ImportHunspell(*dicPath, *affPath, opts)
+	if err != nil {
+		return err
+	}
+	dict.SimplifyTags(parsed, true)
+	options := dict.LanguageCompileOptions(*lang, map[string]any{"reproducible": *reproducible})
+	compiled, err := dict.CompileParsedDict(parsed, options)
+	if err != nil {
+		return err
+	}
+	return dict.SaveCompiledDict(compiled, *outPath, "hunspell", *lang)
+}
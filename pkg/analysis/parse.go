@@ -9,6 +9,9 @@ type Parse struct {
 	NormalForm   string
 	Score        float64
 	MethodsStack []interface{}
+	// LemmaID is the source (OpenCorpora) lemma ID of dictionary words, 0
+	// if unknown.
+	LemmaID uint32
 }
 
 // NewParse creates a new Parse instance.
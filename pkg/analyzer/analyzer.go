@@ -1,13 +1,19 @@
 package analyzer
 
 import (
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"slices"
 	"strings"
+	"sync/atomic"
 
 	"morphy/pkg/analysis"
 	"morphy/pkg/dict"
-	ru "morphy/pkg/lang/ru"
 	"morphy/pkg/tagset"
 	"morphy/pkg/units"
+	"morphy/pkg/utils"
 )
 
 // unitItem couples analyzer unit with a terminal flag.
@@ -17,31 +23,73 @@ type unitItem struct {
 }
 
 // MorphAnalyzer provides morphological parsing using a set of units.
+//
+// Once constructed, a MorphAnalyzer is safe for concurrent use by multiple
+// goroutines, except for AddLexicon and LoadLexicon, which must not run
+// concurrently with other methods.
 type MorphAnalyzer struct {
 	dict     *dict.Dictionary
 	units    []unitItem
 	prob     *ProbabilityEstimator
 	charSubs map[rune]rune
+	language string
+	// cache is nil unless EnableCache was called
+	cache atomic.Pointer[parseCache]
 }
 
-// New creates MorphAnalyzer for dictionary at path with provided units configuration.
-// unitsCfg can contain units.AnalyzerUnit or []units.AnalyzerUnit to denote groups.
-func New(path string, unitsCfg []interface{}) (*MorphAnalyzer, error) {
-	d, err := dict.NewDictionary(path)
+// New creates MorphAnalyzer configured by opts. WithDictPath is required;
+// language, units and character substitutes default to the ones of the
+// dictionary language_code (see WithLanguage), and P(t|w) probabilities are
+// used if the dictionary has them.
+func New(opts ...Option) (*MorphAnalyzer, error) {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.dictPath == "" {
+		return nil, fmt.Errorf("dictionary path is not set")
+	}
+	d, err := dict.NewDictionary(cfg.dictPath)
 	if err != nil {
 		return nil, err
 	}
-	m := &MorphAnalyzer{dict: d, charSubs: ru.CharSubstitutes}
-	m.initUnits(unitsCfg)
-	if pe, err := NewProbabilityEstimator(path); err == nil {
-		m.prob = pe
+	code := cfg.language
+	if code == "" {
+		code = d.LanguageCode()
+	}
+	lang, ok := languages[code]
+	if !ok {
+		if cfg.language != "" {
+			d.Close()
+			return nil, fmt.Errorf("unsupported language %q", cfg.language)
+		}
+		lang = language{units: minimalUnits}
+	}
+	m := &MorphAnalyzer{dict: d, language: code, charSubs: lang.charSubstitutes}
+	if cfg.charSubsSet {
+		m.charSubs = cfg.charSubs
+	}
+	if cfg.unitsSet {
+		m.initUnits(cfg.units)
+	} else {
+		m.initUnits(lang.units())
 	}
+	if cfg.probSet {
+		m.prob = cfg.prob
+	} else if m.prob, err = NewProbabilityEstimator(cfg.dictPath); errors.Is(err, os.ErrNotExist) {
+		m.prob = nil
+	} else if err != nil {
+		d.Close()
+		return nil, err
+	}
+	m.EnableCache(cfg.cacheSize)
 	return m, nil
 }
 
 func (m *MorphAnalyzer) initUnits(cfg []interface{}) {
+	defer m.ClearCache()
 	if cfg == nil {
-		cfg = []interface{}{[]units.AnalyzerUnit{&units.DictionaryAnalyzer{}}, &units.UnknAnalyzer{}}
+		cfg = minimalUnits()
 	}
 	for _, item := range cfg {
 		switch v := item.(type) {
@@ -60,6 +108,9 @@ func (m *MorphAnalyzer) initUnits(cfg []interface{}) {
 	}
 }
 
+// Language returns the language code the analyzer was configured for.
+func (m *MorphAnalyzer) Language() string { return m.language }
+
 // Dictionary returns underlying dictionary.
 func (m *MorphAnalyzer) Dictionary() units.Dictionary { return m.dict }
 
@@ -68,6 +119,13 @@ func (m *MorphAnalyzer) CharSubstitutes() map[rune]rune { return m.charSubs }
 
 // Parse analyzes a word and returns parses.
 func (m *MorphAnalyzer) Parse(word string) []analysis.Parse {
+	if c := m.cache.Load(); c != nil {
+		return cached(c, c.parses, word, m.parse, cloneParses)
+	}
+	return m.parse(word)
+}
+
+func (m *MorphAnalyzer) parse(word string) []analysis.Parse {
 	res := []analysis.Parse{}
 	seen := map[string]struct{}{}
 	wl := strings.ToLower(word)
@@ -85,6 +143,13 @@ func (m *MorphAnalyzer) Parse(word string) []analysis.Parse {
 
 // Tag returns tags for a word.
 func (m *MorphAnalyzer) Tag(word string) []tagset.Tag {
+	if c := m.cache.Load(); c != nil {
+		return cached(c, c.tags, word, m.tag, slices.Clone)
+	}
+	return m.tag(word)
+}
+
+func (m *MorphAnalyzer) tag(word string) []tagset.Tag {
 	res := []tagset.Tag{}
 	seen := map[string]struct{}{}
 	wl := strings.ToLower(word)
@@ -100,11 +165,39 @@ func (m *MorphAnalyzer) Tag(word string) []tagset.Tag {
 	return res
 }
 
+// ParseBatch parses words on a pool of workers goroutines (GOMAXPROCS if
+// workers <= 0). Parses of words[i] are returned in res[i].
+func (m *MorphAnalyzer) ParseBatch(words []string, workers int) [][]analysis.Parse {
+	res := make([][]analysis.Parse, len(words))
+	utils.ParallelFor(len(words), workers, func(i int) {
+		res[i] = m.Parse(words[i])
+	})
+	return res
+}
+
+// TagBatch returns tags of words computed on a pool of workers goroutines
+// (GOMAXPROCS if workers <= 0). Tags of words[i] are returned in res[i].
+func (m *MorphAnalyzer) TagBatch(words []string, workers int) [][]tagset.Tag {
+	res := make([][]tagset.Tag, len(words))
+	utils.ParallelFor(len(words), workers, func(i int) {
+		res[i] = m.Tag(words[i])
+	})
+	return res
+}
+
 // NormalForms returns list of normal forms for word.
 func (m *MorphAnalyzer) NormalForms(word string) []string {
+	if c := m.cache.Load(); c != nil {
+		return cached(c, c.normals, word, m.normalForms, slices.Clone)
+	}
+	return m.normalForms(word)
+}
+
+func (m *MorphAnalyzer) normalForms(word string) []string {
 	seen := map[string]struct{}{}
 	res := []string{}
-	for _, p := range m.Parse(word) {
+	// not m.Parse: a NormalForms call is a single cache lookup
+	for _, p := range m.parse(word) {
 		if _, ok := seen[p.NormalForm]; !ok {
 			seen[p.NormalForm] = struct{}{}
 			res = append(res, p.NormalForm)
@@ -124,6 +217,52 @@ func (m *MorphAnalyzer) GetLexeme(p analysis.Parse) []analysis.Parse {
 	return []analysis.Parse{p}
 }
 
+// KnownWordParses returns an iterator over parses of dictionary words
+// starting with prefix, including words of the user lexicon, in byte order of
+// words (see dict.Dictionary.KnownWords). Parses are the ones the dictionary
+// unit returns, without probability estimation.
+func (m *MorphAnalyzer) KnownWordParses(prefix string) iter.Seq[analysis.Parse] {
+	return func(yield func(analysis.Parse) bool) {
+		u := m.dictionaryUnit()
+		for kw := range m.dict.KnownWords(prefix) {
+			if !yield(u.KnownWordParse(kw)) {
+				return
+			}
+		}
+	}
+}
+
+// Lexemes returns an iterator over dictionary lexemes with normal forms
+// starting with prefix, in byte order of normal forms (see
+// dict.Dictionary.Lexemes). Each lexeme is returned as GetLexeme returns it.
+func (m *MorphAnalyzer) Lexemes(prefix string) iter.Seq[[]analysis.Parse] {
+	return func(yield func([]analysis.Parse) bool) {
+		u := m.dictionaryUnit()
+		for lexeme := range m.dict.Lexemes(prefix) {
+			res := make([]analysis.Parse, len(lexeme))
+			for i, kw := range lexeme {
+				res[i] = u.KnownWordParse(kw)
+			}
+			if !yield(res) {
+				return
+			}
+		}
+	}
+}
+
+// dictionaryUnit returns the dictionary unit of the analyzer or a new one if
+// the units don't include it.
+func (m *MorphAnalyzer) dictionaryUnit() *units.DictionaryAnalyzer {
+	for _, it := range m.units {
+		if u, ok := it.unit.(*units.DictionaryAnalyzer); ok {
+			return u
+		}
+	}
+	u := &units.DictionaryAnalyzer{}
+	u.Init(m)
+	return u
+}
+
 // Normalized returns normalized form parse.
 func (m *MorphAnalyzer) Normalized(p analysis.Parse) analysis.Parse {
 	if len(p.MethodsStack) == 0 {
@@ -140,6 +279,24 @@ func (m *MorphAnalyzer) WordIsKnown(word string) bool {
 	return m.dict.WordIsKnown(strings.ToLower(word), m.charSubs)
 }
 
+// AddLexicon adds user lexemes to the dictionary (see
+// dict.Dictionary.AddLexicon). Parses, lexemes and inflections of user words
+// take priority over dictionary ones. The parse cache is cleared.
+func (m *MorphAnalyzer) AddLexicon(lex *dict.Lexicon) error {
+	defer m.ClearCache()
+	return m.dict.AddLexicon(lex)
+}
+
+// LoadLexicon reads a user lexicon file (see dict.LoadLexicon) and adds it
+// to the dictionary.
+func (m *MorphAnalyzer) LoadLexicon(path string) error {
+	lex, err := dict.LoadLexicon(path)
+	if err != nil {
+		return err
+	}
+	return m.AddLexicon(lex)
+}
+
 // TagClass parses tag string to Tag.
 func (m *MorphAnalyzer) TagClass(tag string) tagset.Tag {
 	t, _ := tagset.New(tag)
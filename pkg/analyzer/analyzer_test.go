@@ -0,0 +1,230 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+
+	"morphy/pkg/dict"
+	uk "morphy/pkg/lang/uk"
+	"morphy/pkg/tagset"
+)
+
+// TestConcurrentParse parses words from many goroutines while other
+// analyzers are created; run with -race.
+func TestConcurrentParse(t *testing.T) {
+	path := saveTestDict(t, testLexemes)
+	m, err := New(WithDictPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := []string{"мама", "Рамы", "мыла", "кошка", "123", "IV", "ёлка", ",", "мама-рама"}
+	for i := 0; i < 5; i++ {
+		words = append(words, words...)
+	}
+	want := make([][]string, len(words))
+	for i, w := range words {
+		for _, p := range m.Parse(w) {
+			want[i] = append(want[i], p.Word+" "+p.NormalForm+" "+p.Tag.String())
+		}
+	}
+
+	m.EnableCache(4)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := New(WithDictPath(path)); err != nil {
+				t.Error(err)
+			}
+			tagset.AddGrammemeToKnown(fmt.Sprintf("Tst%d", g), fmt.Sprintf("тст%d", g), false)
+		}()
+	}
+	got := m.ParseBatch(words, 8)
+	tags := m.TagBatch(words, 0)
+	wg.Wait()
+
+	for i, parses := range got {
+		var s []string
+		for _, p := range parses {
+			s = append(s, p.Word+" "+p.NormalForm+" "+p.Tag.String())
+		}
+		if !reflect.DeepEqual(s, want[i]) {
+			t.Fatalf("ParseBatch: %q: got %q, want %q", words[i], s, want[i])
+		}
+		if wantTags := m.Tag(words[i]); !reflect.DeepEqual(tags[i], wantTags) {
+			t.Fatalf("TagBatch: %q: got %v, want %v", words[i], tags[i], wantTags)
+		}
+	}
+}
+
+func TestParseCache(t *testing.T) {
+	m, err := New(WithDictPath(saveTestDict(t, testLexemes)), WithUnits(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.EnableCache(2)
+	first := m.Parse("мамы")
+	tag, stack := first[0].Tag.String(), len(first[0].MethodsStack)
+	first[0].NormalForm = "changed"
+	*first[0].Tag = m.TagClass("INFN")
+	first[0].MethodsStack[0] = nil
+	got := m.Parse("мамы")
+	if got[0].NormalForm != "мама" || got[0].Tag.String() != tag || len(got[0].MethodsStack) != stack || got[0].MethodsStack[0] == nil {
+		t.Fatalf("cached parse was modified: %+v", got[0])
+	}
+	m.Tag("мамы")
+	m.NormalForms("рыбе")
+	if st := m.CacheStats(); st.Hits != 1 || st.Misses != 3 || st.Len != 3 || st.Size != 2 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+
+	lex := &dict.Lexicon{Entries: []dict.LexiconEntry{{Lemma: "рыба", Like: "рама"}}}
+	if err := m.AddLexicon(lex); err != nil {
+		t.Fatal(err)
+	}
+	if st := m.CacheStats(); st.Len != 0 {
+		t.Fatalf("cache is not cleared after AddLexicon: %+v", st)
+	}
+	if got := m.NormalForms("рыбе"); !reflect.DeepEqual(got, []string{"рыба"}) {
+		t.Fatalf("NormalForms(рыбе) = %q after AddLexicon", got)
+	}
+
+	m.DisableCache()
+	m.Parse("мамы")
+	if st := m.CacheStats(); st != (CacheStats{}) {
+		t.Fatalf("unexpected stats with cache disabled: %+v", st)
+	}
+}
+
+// TestCacheClearDuringParse checks that a result computed before the cache
+// was cleared is not stored.
+func TestCacheClearDuringParse(t *testing.T) {
+	c := newParseCache(2)
+	got := cached(c, c.normals, "мамы", func(string) []string {
+		c.clear()
+		return []string{"мама"}
+	}, slices.Clone)
+	if !reflect.DeepEqual(got, []string{"мама"}) {
+		t.Fatalf("cached = %q", got)
+	}
+	if c.normals.Len() != 0 {
+		t.Fatal("result computed before clear was stored")
+	}
+	cached(c, c.normals, "мамы", func(string) []string { return []string{"мама"} }, slices.Clone)
+	if c.normals.Len() != 1 {
+		t.Fatal("result was not stored")
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	path := saveTestDict(t, testLexemes)
+	if _, err := New(); err == nil {
+		t.Fatal("New without dictionary path succeeded")
+	}
+	if _, err := New(WithDictPath(path), WithLanguage("xx")); err == nil {
+		t.Fatal("New with unsupported language succeeded")
+	}
+	m, err := New(WithDictPath(path), WithCache(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Language() != "ru" || m.CharSubstitutes()['е'] != 'ё' || m.CacheStats().Size != 10 {
+		t.Fatalf("unexpected defaults: language %q, substitutes %v, cache %+v", m.Language(), m.CharSubstitutes(), m.CacheStats())
+	}
+	m, err = New(WithDictPath(path), WithCharSubstitutes(nil), WithProbabilityEstimator(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.CharSubstitutes() != nil || m.prob != nil || m.CacheStats() != (CacheStats{}) {
+		t.Fatal("options are not applied")
+	}
+}
+
+const ukDictXML = `<?xml version="1.0" encoding="utf-8"?>
+<dictionary version="0.1" revision="1">
+<grammemes>
+<grammeme parent=""><name>Supr</name><alias>прев</alias><description></description></grammeme>
+<grammeme parent=""><name>Qual</name><alias>кач</alias><description></description></grammeme>
+<grammeme parent=""><name>Sgtm</name><alias>sg</alias><description></description></grammeme>
+<grammeme parent=""><name>Fixd</name><alias>0</alias><description></description></grammeme>
+<grammeme parent=""><name>Abbr</name><alias>аббр</alias><description></description></grammeme>
+<grammeme parent=""><name>Name</name><alias>имя</alias><description></description></grammeme>
+<grammeme parent=""><name>Patr</name><alias>отч</alias><description></description></grammeme>
+</grammemes>
+<lemmata>
+<lemma id="1"><l t="м'ясо"><g v="NOUN"/><g v="inan"/><g v="neut"/></l><f t="м'ясо"><g v="sing"/><g v="nomn"/></f><f t="м'яса"><g v="sing"/><g v="gent"/></f><f t="м'ясом"><g v="sing"/><g v="ablt"/></f></lemma>
+<lemma id="2"><l t="кращий"><g v="ADJF"/><g v="Qual"/></l><f t="кращий"><g v="masc"/><g v="sing"/><g v="nomn"/></f><f t="кращого"><g v="masc"/><g v="sing"/><g v="gent"/></f><f t="найкращий"><g v="Supr"/><g v="masc"/><g v="sing"/><g v="nomn"/></f><f t="найкращого"><g v="Supr"/><g v="masc"/><g v="sing"/><g v="gent"/></f></lemma>
+</lemmata>
+</dictionary>`
+
+func TestUkrainian(t *testing.T) {
+	dir := t.TempDir()
+	xmlPath, path := filepath.Join(dir, "dict.xml"), filepath.Join(dir, "dict")
+	if err := os.WriteFile(xmlPath, []byte(ukDictXML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	options := map[string]any{"min_paradigm_popularity": 1}
+	if err := dict.ConvertToPymorphy2(xmlPath, path, "test", "uk", false, options); err != nil {
+		t.Fatal(err)
+	}
+	d, err := dict.NewDictionary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.ParadigmPrefixes(); !reflect.DeepEqual(got, uk.ParadigmPrefixes) {
+		t.Errorf("ParadigmPrefixes() = %q, want %q", got, uk.ParadigmPrefixes)
+	}
+	d.Close()
+	m, err := New(WithDictPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Language() != "uk" {
+		t.Fatalf("Language() = %q, want uk", m.Language())
+	}
+	for word, want := range map[string]string{
+		"м’ясом":      "м'ясо",
+		"Мʼяса":       "м'ясо",
+		"найкращого":  "кращий",
+		"супермʼясом": "суперм'ясо",
+	} {
+		if got := m.NormalForms(word); len(got) == 0 || got[0] != want {
+			t.Errorf("NormalForms(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestKnownWordParses(t *testing.T) {
+	m, err := New(WithDictPath(saveTestDict(t, testLexemes)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var words []string
+	for p := range m.KnownWordParses("ма") {
+		words = append(words, p.Word)
+		if got := m.Parse(p.Word)[0]; got.NormalForm != p.NormalForm || got.Tag.String() != p.Tag.String() {
+			t.Errorf("KnownWordParses: %q: got %s %s, want %s %s", p.Word, p.NormalForm, p.Tag, got.NormalForm, got.Tag)
+		}
+	}
+	if want := []string{"мама", "маме", "мамы"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("KnownWordParses(ма) = %q, want %q", words, want)
+	}
+
+	var lemmas []string
+	for lexeme := range m.Lexemes("") {
+		lemmas = append(lemmas, lexeme[0].Word)
+		want := m.GetLexeme(lexeme[len(lexeme)-1])
+		if len(want) != len(lexeme) || want[0].Word != lexeme[0].Word || want[0].Tag.String() != lexeme[0].Tag.String() {
+			t.Errorf("Lexemes: %v does not match GetLexeme %v", lexeme, want)
+		}
+	}
+	if want := []string{"мама", "мыть", "рама"}; !reflect.DeepEqual(lemmas, want) {
+		t.Errorf("Lexemes = %q, want %q", lemmas, want)
+	}
+}
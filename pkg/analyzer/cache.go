@@ -0,0 +1,122 @@
+package analyzer
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"morphy/pkg/analysis"
+	"morphy/pkg/tagset"
+	"morphy/pkg/utils"
+)
+
+// CacheStats holds counters of the parse cache.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	// Len is the number of cached results of Parse, Tag and NormalForms.
+	Len int
+	// Size is the maximum number of words cached for each method.
+	Size int
+}
+
+// parseCache keeps results of Parse, Tag and NormalForms by the raw word.
+type parseCache struct {
+	size int
+	mu   sync.Mutex
+	// gen is incremented by clear, so results computed before it are not
+	// stored.
+	gen     uint64
+	parses  *utils.LRUCache[string, []analysis.Parse]
+	tags    *utils.LRUCache[string, []tagset.Tag]
+	normals *utils.LRUCache[string, []string]
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+}
+
+func newParseCache(size int) *parseCache {
+	return &parseCache{
+		size:    size,
+		parses:  utils.NewLRUCache[string, []analysis.Parse](size),
+		tags:    utils.NewLRUCache[string, []tagset.Tag](size),
+		normals: utils.NewLRUCache[string, []string](size),
+	}
+}
+
+// cached returns a copy (made by clone) of the result of fn(word) from lru,
+// computing and storing it on a miss. A result is not stored if the cache
+// was cleared while it was computed.
+func cached[V any](c *parseCache, lru *utils.LRUCache[string, []V], word string, fn func(string) []V, clone func([]V) []V) []V {
+	c.mu.Lock()
+	res, ok := lru.Get(word)
+	gen := c.gen
+	c.mu.Unlock()
+	if ok {
+		c.hits.Add(1)
+		return clone(res)
+	}
+	c.misses.Add(1)
+	res = fn(word)
+	c.mu.Lock()
+	if c.gen == gen {
+		lru.Set(word, res)
+	}
+	c.mu.Unlock()
+	return clone(res)
+}
+
+// cloneParses copies parses together with their tags and methods stacks.
+func cloneParses(parses []analysis.Parse) []analysis.Parse {
+	res := slices.Clone(parses)
+	for i, p := range res {
+		if p.Tag != nil {
+			tag := *p.Tag
+			res[i].Tag = &tag
+		}
+		res[i].MethodsStack = slices.Clone(p.MethodsStack)
+	}
+	return res
+}
+
+func (c *parseCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.parses.Clear()
+	c.tags.Clear()
+	c.normals.Clear()
+}
+
+// EnableCache turns on caching of Parse, Tag and NormalForms results for up
+// to size most recently used words each; size <= 0 turns caching off.
+// Statistics are reset. The cache is safe for concurrent use and is cleared
+// when the dictionary changes (see AddLexicon).
+func (m *MorphAnalyzer) EnableCache(size int) {
+	if size <= 0 {
+		m.DisableCache()
+		return
+	}
+	m.cache.Store(newParseCache(size))
+}
+
+// DisableCache turns caching off and drops cached results.
+func (m *MorphAnalyzer) DisableCache() { m.cache.Store(nil) }
+
+// ClearCache drops cached results, keeping statistics.
+func (m *MorphAnalyzer) ClearCache() {
+	if c := m.cache.Load(); c != nil {
+		c.clear()
+	}
+}
+
+// CacheStats returns statistics of the cache; it is zero when caching is off.
+func (m *MorphAnalyzer) CacheStats() CacheStats {
+	c := m.cache.Load()
+	if c == nil {
+		return CacheStats{}
+	}
+	c.mu.Lock()
+	n := c.parses.Len() + c.tags.Len() + c.normals.Len()
+	c.mu.Unlock()
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load(), Len: n, Size: c.size}
+}
@@ -0,0 +1,137 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"morphy/pkg/dawg"
+	"morphy/pkg/dict"
+)
+
+// ProbabilityFile is the name of P(t|w) data inside a dictionary directory.
+const ProbabilityFile = "p_t_given_w.intdawg"
+
+// ConditionalFreqDist counts tags of words. Tags are keyed by their sorted
+// grammemes, so grammeme order does not matter.
+type ConditionalFreqDist map[string]map[string]int
+
+// N returns the total number of counted outcomes.
+func (c ConditionalFreqDist) N() int {
+	n := 0
+	for _, fd := range c {
+		for _, count := range fd {
+			n += count
+		}
+	}
+	return n
+}
+
+// EstimateConditionalFreq counts tags of disambiguated corpus words (see
+// dict.DisambiguatedWords). Only words ambiguous for m are counted; UNKN
+// tags are skipped.
+func EstimateConditionalFreq(m *MorphAnalyzer, words []dict.WordForm) ConditionalFreqDist {
+	cfd := ConditionalFreqDist{}
+	ambiguous := map[string]bool{}
+	for _, w := range words {
+		amb, ok := ambiguous[w.Word]
+		if !ok {
+			amb = len(m.Tag(w.Word)) > 1
+			ambiguous[w.Word] = amb
+		}
+		if !amb {
+			continue
+		}
+		grams := dict.TagGrammemes(w.Tag)
+		if len(grams) == 1 && grams[0] == "UNKN" {
+			continue
+		}
+		word := strings.ToLower(w.Word)
+		if cfd[word] == nil {
+			cfd[word] = map[string]int{}
+		}
+		cfd[word][grammemesKey(grams)]++
+	}
+	return cfd
+}
+
+// ConditionalProbDist estimates P(t|w) for words counted at least
+// minWordFreq times using Laplace smoothing; the number of bins is the
+// larger of the number of word tags and the number of observed tags. Words
+// whose tags all get the same probability are skipped. The result maps
+// words to probabilities of their tags.
+func ConditionalProbDist(m *MorphAnalyzer, cfd ConditionalFreqDist, minWordFreq int) map[string]map[string]float64 {
+	res := map[string]map[string]float64{}
+	for word, fd := range cfd {
+		n := 0
+		for _, count := range fd {
+			n += count
+		}
+		if n < minWordFreq {
+			continue
+		}
+		bins := max(len(m.Tag(word)), len(fd))
+		probs := map[string]float64{}
+		for _, p := range m.Parse(word) {
+			count := fd[grammemesKey(p.Tag.Grammemes())]
+			probs[p.Tag.String()] = float64(count+1) / float64(n+bins)
+		}
+		if allTheSame(probs) {
+			continue
+		}
+		res[word] = probs
+	}
+	return res
+}
+
+// AddConditionalTagProbability estimates P(t|w) from annotated OpenCorpora
+// corpus at corpusPath and saves it to the dictionary at dictPath.
+func AddConditionalTagProbability(corpusPath, dictPath string, minWordFreq int) error {
+	m, err := New(WithDictPath(dictPath), WithProbabilityEstimator(nil))
+	if err != nil {
+		return err
+	}
+	words, err := dict.DisambiguatedWords(corpusPath)
+	if err != nil {
+		return err
+	}
+	cfd := EstimateConditionalFreq(m, words)
+	cpd := ConditionalProbDist(m, cfd, minWordFreq)
+	if err := dawg.WriteConditionalProbDist(filepath.Join(dictPath, ProbabilityFile), cpd); err != nil {
+		return err
+	}
+	return dict.UpdateMeta(dictPath, map[string]any{
+		"P(t|w)":               true,
+		"P(t|w)_unique_words":  len(cfd),
+		"P(t|w)_outcomes":      cfd.N(),
+		"P(t|w)_min_word_freq": minWordFreq,
+	})
+}
+
+func grammemesKey(grams []string) string {
+	set := make([]string, 0, len(grams))
+	for _, g := range grams {
+		set = append(set, strings.TrimSpace(g))
+	}
+	sort.Strings(set)
+	uniq := set[:0]
+	for i, g := range set {
+		if i == 0 || g != set[i-1] {
+			uniq = append(uniq, g)
+		}
+	}
+	return strings.Join(uniq, ",")
+}
+
+func allTheSame(probs map[string]float64) bool {
+	first := true
+	var v float64
+	for _, p := range probs {
+		if first {
+			v, first = p, false
+		} else if p != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"morphy/pkg/dawg"
+	"morphy/pkg/dict"
+)
+
+// corpusToken returns an annotated corpus token with the given parses, each
+// a comma-separated list of grammemes.
+func corpusToken(text string, tags ...string) string {
+	var b strings.Builder
+	b.WriteString(`<token text="` + text + `"><tfr t="` + text + `">`)
+	for _, tag := range tags {
+		b.WriteString(`<v><l t="` + strings.ToLower(text) + `">`)
+		for _, g := range strings.Split(tag, ",") {
+			b.WriteString(`<g v="` + g + `"/>`)
+		}
+		b.WriteString(`</l></v>`)
+	}
+	b.WriteString("</tfr></token>\n")
+	return b.String()
+}
+
+func TestAddConditionalTagProbability(t *testing.T) {
+	const (
+		verb = "VERB,perf,intr plur,past,indc"
+		gent = "NOUN,inan,femn sing,gent"
+		nomn = "NOUN,inan,femn plur,nomn"
+	)
+	path := saveTestDict(t, map[string][]dict.WordForm{
+		"1": {{Word: "сталь", Tag: "NOUN,inan,femn sing,nomn"}, {Word: "стали", Tag: gent}, {Word: "стали", Tag: nomn}},
+		"2": {{Word: "стать", Tag: "INFN,perf,intr"}, {Word: "стали", Tag: verb}},
+		"3": {{Word: "мыло", Tag: "NOUN,inan,neut sing,nomn"}, {Word: "мыла", Tag: "NOUN,inan,neut sing,gent"}},
+		"4": {{Word: "мыть", Tag: "INFN,impf,tran"}, {Word: "мыла", Tag: "VERB,impf,tran femn,sing,past,indc"}},
+		"5": {{Word: "мама", Tag: "NOUN,anim,femn sing,nomn"}},
+	})
+
+	corpus := corpusToken("Стали", "VERB,perf,intr,plur,past,indc") +
+		corpusToken("стали", "VERB,perf,intr,plur,past,indc") +
+		corpusToken("стали", "VERB,perf,intr,plur,past,indc") +
+		corpusToken("стали", "NOUN,inan,femn,sing,gent") +
+		// not disambiguated
+		corpusToken("стали", "NOUN,inan,femn,sing,gent", "NOUN,inan,femn,plur,nomn") +
+		// seen less than min_word_freq times
+		corpusToken("мыла", "NOUN,inan,neut,sing,gent") +
+		// not ambiguous
+		corpusToken("мама", "NOUN,anim,femn,sing,nomn") +
+		// not in the dictionary, parsed by the prefix units
+		corpusToken("застали", "VERB,perf,intr,plur,past,indc") +
+		corpusToken("застали", "VERB,perf,intr,plur,past,indc")
+	corpusPath := filepath.Join(t.TempDir(), "corpus.xml")
+	xml := `<annotation><text><paragraphs><paragraph><sentence><tokens>` + "\n" + corpus + `</tokens></sentence></paragraph></paragraphs></text></annotation>`
+	if err := os.WriteFile(corpusPath, []byte(xml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AddConditionalTagProbability(corpusPath, path, 2); err != nil {
+		t.Fatal(err)
+	}
+	cpd, err := dawg.LoadConditionalProbDist(filepath.Join(path, ProbabilityFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 4 observations, 3 bins: P = (count+1) / (4+3)
+	want := map[string]float64{verb: 4.0 / 7, gent: 2.0 / 7, nomn: 1.0 / 7}
+	got := cpd.Probs("стали")
+	if len(got) != len(want) {
+		t.Fatalf("Probs(стали) = %v, want %v", got, want)
+	}
+	for tag, p := range want {
+		// probabilities are stored as integers, see dawg.MULTIPLIER
+		if d := got[tag] - p; d > 1.0/dawg.MULTIPLIER || d < -1.0/dawg.MULTIPLIER {
+			t.Errorf("P(%s|стали) = %v, want %v", tag, got[tag], p)
+		}
+	}
+	// 2 observations, 3 bins
+	if got, want := cpd.Prob("застали", verb), 3.0/5; got-want > 1.0/dawg.MULTIPLIER || want-got > 1.0/dawg.MULTIPLIER {
+		t.Errorf("P(%s|застали) = %v, want %v", verb, got, want)
+	}
+	for _, word := range []string{"мыла", "мама"} {
+		if got := cpd.Probs(word); len(got) != 0 {
+			t.Errorf("Probs(%s) = %v, want none", word, got)
+		}
+	}
+
+	ld, err := dict.LoadDict(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := map[string]any{}
+	for _, key := range []string{"P(t|w)", "P(t|w)_unique_words", "P(t|w)_outcomes", "P(t|w)_min_word_freq"} {
+		meta[key] = ld.Meta[key]
+	}
+	wantMeta := map[string]any{"P(t|w)": true, "P(t|w)_unique_words": 3.0, "P(t|w)_outcomes": 7.0, "P(t|w)_min_word_freq": 2.0}
+	if !reflect.DeepEqual(meta, wantMeta) {
+		t.Errorf("meta = %v, want %v", meta, wantMeta)
+	}
+}
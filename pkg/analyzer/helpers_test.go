@@ -0,0 +1,48 @@
+package analyzer
+
+import (
+	"testing"
+
+	"morphy/pkg/dict"
+)
+
+// testLexemes make the dictionary most tests of the package use.
+var testLexemes = map[string][]dict.WordForm{
+	"1": {
+		{Word: "мама", Tag: "NOUN,anim,femn sing,nomn"},
+		{Word: "мамы", Tag: "NOUN,anim,femn sing,gent"},
+		{Word: "маме", Tag: "NOUN,anim,femn sing,datv"},
+	},
+	"2": {
+		{Word: "рама", Tag: "NOUN,inan,femn sing,nomn"},
+		{Word: "рамы", Tag: "NOUN,inan,femn sing,gent"},
+		{Word: "раме", Tag: "NOUN,inan,femn sing,datv"},
+	},
+	"3": {
+		{Word: "мыть", Tag: "INFN,impf,tran"},
+		{Word: "мыла", Tag: "VERB,impf,tran femn,sing,past,indc"},
+	},
+}
+
+// testGrammemes are the grammemes the default units of "ru" need.
+var testGrammemes = []dict.Grammeme{
+	{Name: "NOUN", Alias: "СУЩ"}, {Name: "INFN", Alias: "ИНФ"},
+	{Name: "Sgtm", Alias: "sg"}, {Name: "Fixd", Alias: "0"}, {Name: "Abbr", Alias: "аббр"},
+	{Name: "Name", Alias: "имя"}, {Name: "Patr", Alias: "отч"},
+}
+
+// saveTestDict compiles a "ru" dictionary of lexemes to a temporary
+// directory and returns its path.
+func saveTestDict(t *testing.T, lexemes map[string][]dict.WordForm) string {
+	t.Helper()
+	parsed := &dict.ParsedDictionary{Lexemes: lexemes, Grammemes: testGrammemes}
+	compiled, err := dict.CompileParsedDict(parsed, map[string]any{"min_paradigm_popularity": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := t.TempDir()
+	if err := dict.SaveCompiledDict(compiled, path, "test", "ru"); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,85 @@
+package analyzer
+
+import (
+	ru "morphy/pkg/lang/ru"
+	uk "morphy/pkg/lang/uk"
+	"morphy/pkg/units"
+)
+
+// Option configures MorphAnalyzer created by New.
+type Option func(*config)
+
+type config struct {
+	dictPath    string
+	language    string
+	units       []interface{}
+	unitsSet    bool
+	charSubs    map[rune]rune
+	charSubsSet bool
+	prob        *ProbabilityEstimator
+	probSet     bool
+	cacheSize   int
+}
+
+// WithDictPath sets the path of the compiled dictionary.
+func WithDictPath(path string) Option {
+	return func(c *config) { c.dictPath = path }
+}
+
+// WithLanguage selects default units and character substitutes of language
+// code instead of the ones of the dictionary language_code. Languages are
+// configured in pkg/lang/<code>.
+func WithLanguage(code string) Option {
+	return func(c *config) { c.language = code }
+}
+
+// WithUnits sets analyzer units. cfg items are units.AnalyzerUnit values or
+// []units.AnalyzerUnit groups, in which only the last unit is terminal. Nil
+// cfg means a dictionary unit followed by units.UnknAnalyzer.
+func WithUnits(cfg []interface{}) Option {
+	return func(c *config) {
+		c.units = cfg
+		c.unitsSet = true
+	}
+}
+
+// WithCharSubstitutes sets characters which may be replaced in dictionary
+// lookups, e.g. 'е' with 'ё'; nil disables substitutes.
+func WithCharSubstitutes(subs map[rune]rune) Option {
+	return func(c *config) {
+		c.charSubs = subs
+		c.charSubsSet = true
+	}
+}
+
+// WithProbabilityEstimator sets the estimator of parse scores; nil disables
+// P(t|w) estimation even if the dictionary has the data.
+func WithProbabilityEstimator(pe *ProbabilityEstimator) Option {
+	return func(c *config) {
+		c.prob = pe
+		c.probSet = true
+	}
+}
+
+// WithCache enables the parse cache of size words (see
+// MorphAnalyzer.EnableCache).
+func WithCache(size int) Option {
+	return func(c *config) { c.cacheSize = size }
+}
+
+// language holds per-language defaults of MorphAnalyzer.
+type language struct {
+	units           func() []interface{}
+	charSubstitutes map[rune]rune
+}
+
+// languages maps language codes to their defaults from pkg/lang/<code>.
+var languages = map[string]language{
+	"ru": {units: ru.DefaultUnits, charSubstitutes: ru.CharSubstitutes},
+	"uk": {units: uk.DefaultUnits, charSubstitutes: uk.CharSubstitutes},
+}
+
+// minimalUnits are used for languages without a configuration.
+func minimalUnits() []interface{} {
+	return []interface{}{[]units.AnalyzerUnit{&units.DictionaryAnalyzer{}}, &units.UnknAnalyzer{}}
+}
@@ -17,7 +17,7 @@ type ProbabilityEstimator struct {
 
 // NewProbabilityEstimator loads probabilities from dictionary path.
 func NewProbabilityEstimator(dictPath string) (*ProbabilityEstimator, error) {
-	file := filepath.Join(dictPath, "p_t_given_w.intdawg")
+	file := filepath.Join(dictPath, ProbabilityFile)
 	probs, err := dawg.LoadConditionalProbDist(file)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -33,10 +33,11 @@ func (pe *ProbabilityEstimator) ApplyToParses(word, wordLower string, parses []a
 	if pe == nil || len(parses) == 0 {
 		return parses
 	}
+	dist := pe.probs.Probs(wordLower)
 	probs := make([]float64, len(parses))
 	sum := 0.0
 	for i, p := range parses {
-		prob := pe.probs.Prob(wordLower, p.Tag.String())
+		prob := dist[p.Tag.String()]
 		probs[i] = prob
 		sum += prob
 	}
@@ -70,9 +71,10 @@ func (pe *ProbabilityEstimator) ApplyToTags(word, wordLower string, tags []tagse
 	if pe == nil || len(tags) == 0 {
 		return tags
 	}
+	dist := pe.probs.Probs(wordLower)
 	for i := 0; i < len(tags)-1; i++ {
 		for j := i + 1; j < len(tags); j++ {
-			if pe.probs.Prob(wordLower, tags[j].String()) > pe.probs.Prob(wordLower, tags[i].String()) {
+			if dist[tags[j].String()] > dist[tags[i].String()] {
 				tags[i], tags[j] = tags[j], tags[i]
 			}
 		}
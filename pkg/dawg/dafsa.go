@@ -0,0 +1,309 @@
+package dawg
+
+import (
+	"encoding/binary"
+	"sort"
+	"unicode/utf8"
+)
+
+// automaton is a minimal deterministic acyclic finite state automaton built
+// from sorted keys. Every node keeps its edges sorted by label; every edge
+// knows how many keys precede its subtree among the node's keys, so walking
+// a key yields its index in sorted order.
+type automaton struct {
+	edgeStart []uint32 // edges of node n are edgeStart[n]:edgeStart[n+1]
+	labels    []byte
+	targets   []uint32
+	ranks     []uint32
+	final     []bool
+}
+
+// dafsa is a store keeping keys in an automaton and values in key order.
+type dafsa[T any] struct {
+	automaton
+	values [][]T
+}
+
+// dafsaState is a position in the automaton: a node and the index of the
+// first key below it.
+type dafsaState struct {
+	node uint32
+	rank uint32
+}
+
+func newDAFSA[T any](data map[string][]T) *dafsa[T] {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b := newDAFSABuilder()
+	for _, k := range keys {
+		b.insert(k, 0)
+	}
+	d := &dafsa[T]{automaton: b.finish()}
+	d.values = make([][]T, len(keys))
+	for i, k := range keys {
+		d.values[i] = data[k]
+	}
+	return d
+}
+
+func (a *automaton) followByte(s dafsaState, label byte) (dafsaState, bool) {
+	from, to := int(a.edgeStart[s.node]), int(a.edgeStart[s.node+1])
+	i := from + sort.Search(to-from, func(i int) bool { return a.labels[from+i] >= label })
+	if i == to || a.labels[i] != label {
+		return s, false
+	}
+	return dafsaState{node: a.targets[i], rank: s.rank + a.ranks[i]}, true
+}
+
+func (a *automaton) follow(s dafsaState, key string) (dafsaState, bool) {
+	for i := 0; i < len(key); i++ {
+		var ok bool
+		if s, ok = a.followByte(s, key[i]); !ok {
+			return s, false
+		}
+	}
+	return s, true
+}
+
+func (d *dafsa[T]) get(key string) ([]T, bool) {
+	s, ok := d.follow(dafsaState{}, key)
+	if !ok || !d.final[s.node] {
+		return nil, false
+	}
+	return d.values[s.rank], true
+}
+
+func (d *dafsa[T]) has(key string) bool {
+	s, ok := d.follow(dafsaState{}, key)
+	return ok && d.final[s.node]
+}
+
+func (d *dafsa[T]) items() map[string][]T {
+	res := make(map[string][]T, len(d.values))
+	d.walk("", func(key string, vals []T) bool {
+		res[key] = vals
+		return true
+	})
+	return res
+}
+
+func (d *dafsa[T]) walk(prefix string, fn func(key string, vals []T) bool) {
+	s, ok := d.follow(dafsaState{}, prefix)
+	if !ok {
+		return
+	}
+	key := []byte(prefix)
+	rank := s.rank
+	var visit func(node uint32) bool
+	visit = func(node uint32) bool {
+		if d.final[node] {
+			if !fn(string(key), d.values[rank]) {
+				return false
+			}
+			rank++
+		}
+		for i := d.edgeStart[node]; i < d.edgeStart[node+1]; i++ {
+			key = append(key, d.labels[i])
+			if !visit(d.targets[i]) {
+				return false
+			}
+			key = key[:len(key)-1]
+		}
+		return true
+	}
+	visit(s.node)
+}
+
+func (d *dafsa[T]) prefixes(word string) []string {
+	res := make([]string, 0)
+	s := dafsaState{}
+	for i := 0; i < len(word); i++ {
+		var ok bool
+		if s, ok = d.followByte(s, word[i]); !ok {
+			break
+		}
+		if d.final[s.node] {
+			res = append(res, word[:i+1])
+		}
+	}
+	return res
+}
+
+func (d *dafsa[T]) similar(word string, subs map[rune]rune, fn func(key string, vals []T)) {
+	similarWalk(word, subs, dafsaState{}, d.follow, func(key string, s dafsaState) {
+		if d.final[s.node] {
+			fn(key, d.values[s.rank])
+		}
+	})
+}
+
+// similarWalk follows word from start trying every substitution from subs
+// along the way, so only existing paths are explored. emit is called for
+// every fully matched variant in byte order of substituted characters.
+func similarWalk[S any](word string, subs map[rune]rune, start S, follow func(S, string) (S, bool), emit func(key string, s S)) {
+	key := make([]byte, 0, len(word))
+	var visit func(pos int, s S)
+	visit = func(pos int, s S) {
+		if pos == len(word) {
+			emit(string(key), s)
+			return
+		}
+		r, size := utf8.DecodeRuneInString(word[pos:])
+		options := []string{word[pos : pos+size]}
+		if sub, ok := subs[r]; ok && sub != r {
+			options = append(options, string(sub))
+			if options[1] < options[0] {
+				options[0], options[1] = options[1], options[0]
+			}
+		}
+		for _, o := range options {
+			next, ok := follow(s, o)
+			if !ok {
+				continue
+			}
+			key = append(key, o...)
+			visit(pos+size, next)
+			key = key[:len(key)-len(o)]
+		}
+	}
+	visit(0, start)
+}
+
+// dafsaBuilder implements the incremental construction of a minimal
+// automaton from sorted keys (Daciuk et al.). Nodes of the previously
+// inserted key stay unchecked until a key with a shorter common prefix
+// arrives; then they are replaced by equivalent registered nodes.
+type dafsaBuilder struct {
+	nodes     []builderNode
+	register  map[string]uint32
+	unchecked []uint32 // nodes along the previous key, root first
+	prev      string
+}
+
+type builderNode struct {
+	final   bool
+	value   uint32
+	labels  []byte
+	targets []uint32
+}
+
+func newDAFSABuilder() *dafsaBuilder {
+	return &dafsaBuilder{
+		nodes:     []builderNode{{}},
+		register:  map[string]uint32{},
+		unchecked: []uint32{0},
+	}
+}
+
+// insert adds key with value; keys must be inserted in ascending byte order.
+func (b *dafsaBuilder) insert(key string, value uint32) {
+	common := 0
+	for common < len(key) && common < len(b.prev) && key[common] == b.prev[common] {
+		common++
+	}
+	b.minimize(common)
+	node := b.unchecked[common]
+	for i := common; i < len(key); i++ {
+		child := uint32(len(b.nodes))
+		b.nodes = append(b.nodes, builderNode{})
+		n := &b.nodes[node]
+		n.labels = append(n.labels, key[i])
+		n.targets = append(n.targets, child)
+		b.unchecked = append(b.unchecked, child)
+		node = child
+	}
+	b.nodes[node].final = true
+	b.nodes[node].value = value
+	b.prev = key
+}
+
+// minimize replaces unchecked nodes deeper than depth with their registered
+// equivalents.
+func (b *dafsaBuilder) minimize(depth int) {
+	for i := len(b.unchecked) - 1; i > depth; i-- {
+		child := b.unchecked[i]
+		parent := &b.nodes[b.unchecked[i-1]]
+		sig := b.signature(child)
+		if id, ok := b.register[sig]; ok {
+			parent.targets[len(parent.targets)-1] = id
+		} else {
+			b.register[sig] = child
+		}
+	}
+	b.unchecked = b.unchecked[:depth+1]
+}
+
+func (b *dafsaBuilder) signature(node uint32) string {
+	n := &b.nodes[node]
+	sig := make([]byte, 1, 5+5*len(n.labels))
+	if n.final {
+		sig[0] = 1
+		sig = binary.LittleEndian.AppendUint32(sig, n.value)
+	}
+	for i, l := range n.labels {
+		sig = append(sig, l)
+		sig = binary.LittleEndian.AppendUint32(sig, n.targets[i])
+	}
+	return string(sig)
+}
+
+// finish minimizes the remaining nodes and returns the compacted automaton.
+// Nodes are renumbered in breadth-first order from the root.
+func (b *dafsaBuilder) finish() automaton {
+	b.minimize(0)
+
+	ids := map[uint32]uint32{0: 0}
+	order := []uint32{0}
+	for i := 0; i < len(order); i++ {
+		for _, t := range b.nodes[order[i]].targets {
+			if _, ok := ids[t]; !ok {
+				ids[t] = uint32(len(order))
+				order = append(order, t)
+			}
+		}
+	}
+
+	counts := make(map[uint32]uint32, len(order))
+	var count func(node uint32) uint32
+	count = func(node uint32) uint32 {
+		if c, ok := counts[node]; ok {
+			return c
+		}
+		n := &b.nodes[node]
+		c := uint32(0)
+		if n.final {
+			c = 1
+		}
+		for _, t := range n.targets {
+			c += count(t)
+		}
+		counts[node] = c
+		return c
+	}
+
+	a := automaton{
+		edgeStart: make([]uint32, 0, len(order)+1),
+		final:     make([]bool, len(order)),
+	}
+	for i, old := range order {
+		n := &b.nodes[old]
+		a.final[i] = n.final
+		a.edgeStart = append(a.edgeStart, uint32(len(a.labels)))
+		rank := uint32(0)
+		if n.final {
+			rank = 1
+		}
+		for j, t := range n.targets {
+			a.labels = append(a.labels, n.labels[j])
+			a.targets = append(a.targets, ids[t])
+			a.ranks = append(a.ranks, rank)
+			rank += count(t)
+		}
+	}
+	a.edgeStart = append(a.edgeStart, uint32(len(a.labels)))
+	return a
+}
@@ -1,25 +1,41 @@
 package dawg
 
-// DAWG is a minimal map-backed replacement for a Directed Acyclic Word Graph.
-// It stores a mapping from string keys to a slice of values of generic type T.
-// It also provides prefix-based queries used by the morphological analyzer.
+import "iter"
+
+// DAWG is a Directed Acyclic Word Graph. It stores a mapping from string
+// keys to a slice of values of generic type T and provides prefix-based
+// queries used by the morphological analyzer. Keys are kept in a minimal
+// automaton (see New), in a binary table (see NewFromTable) or in a
+// RecordDAWG file (see LoadRecordDAWG).
 type DAWG[T any] struct {
-	data map[string][]T
+	store store[T]
+}
+
+// store is a read-only key/values storage backing a DAWG.
+type store[T any] interface {
+	get(key string) ([]T, bool)
+	has(key string) bool
+	items() map[string][]T
+	// walk calls fn for keys starting with prefix in byte order until fn
+	// returns false.
+	walk(prefix string, fn func(key string, vals []T) bool)
+	// prefixes returns stored keys which are prefixes of word.
+	prefixes(word string) []string
+	// similar calls fn for stored keys obtainable from word by
+	// substitutions.
+	similar(word string, subs map[rune]rune, fn func(key string, vals []T))
 }
 
-// New creates a DAWG instance from the provided data map. The map is used as-is;
-// callers should not modify it after passing to New unless such modifications
-// are intentional.
+// New creates a DAWG instance from the provided data map. Keys are compiled
+// into a minimal automaton; value slices are kept as-is, callers should not
+// modify them after passing to New.
 func New[T any](data map[string][]T) *DAWG[T] {
-	if data == nil {
-		data = make(map[string][]T)
-	}
-	return &DAWG[T]{data: data}
+	return &DAWG[T]{store: newDAFSA(data)}
 }
 
 // Items returns a copy of values associated with the key.
 func (d *DAWG[T]) Items(key string) []T {
-	vals, ok := d.data[key]
+	vals, ok := d.store.get(key)
 	if !ok {
 		return nil
 	}
@@ -30,29 +46,33 @@ func (d *DAWG[T]) Items(key string) []T {
 
 // Prefixes returns all prefixes of word that exist in the DAWG.
 func (d *DAWG[T]) Prefixes(word string) []string {
-	res := make([]string, 0)
-	for i := 1; i <= len(word); i++ {
-		if _, ok := d.data[word[:i]]; ok {
-			res = append(res, word[:i])
-		}
-	}
-	return res
+	return d.store.prefixes(word)
 }
 
 // IsPrefixed reports whether word has at least one prefix stored in the DAWG.
 func (d *DAWG[T]) IsPrefixed(word string) bool {
-	for i := 1; i <= len(word); i++ {
-		if _, ok := d.data[word[:i]]; ok {
-			return true
-		}
+	return len(d.store.prefixes(word)) > 0
+}
+
+// Walk calls fn for every key starting with prefix and its values, in byte
+// order of keys, until fn returns false.
+func (d *DAWG[T]) Walk(prefix string, fn func(key string, vals []T) bool) {
+	d.store.walk(prefix, fn)
+}
+
+// All returns an iterator over keys starting with prefix and their values,
+// in byte order of keys. Values must not be modified.
+func (d *DAWG[T]) All(prefix string) iter.Seq2[string, []T] {
+	return func(yield func(string, []T) bool) {
+		d.store.walk(prefix, yield)
 	}
-	return false
 }
 
-// Data returns the underlying map. It is intended for serialization helpers
-// and callers should treat the returned map as read-only.
+// Data returns all stored data as a map. It is intended for serialization
+// helpers and callers should treat the returned map as read-only. For
+// table-backed DAWGs the map is decoded on every call.
 func (d *DAWG[T]) Data() map[string][]T {
-	return d.data
+	return d.store.items()
 }
 
 // SimilarItems returns all stored keys obtainable from the given word by
@@ -60,58 +80,30 @@ func (d *DAWG[T]) Data() map[string][]T {
 // substituted words to their associated values.
 func (d *DAWG[T]) SimilarItems(word string, subs map[rune]rune) map[string][]T {
 	res := map[string][]T{}
-	for _, variant := range generateVariants(word, subs) {
-		if vals, ok := d.data[variant]; ok {
-			res[variant] = vals
-		}
-	}
+	d.store.similar(word, subs, func(key string, vals []T) {
+		res[key] = vals
+	})
 	return res
 }
 
 // SimilarItemValues returns values for all words similar to the given one
-// according to the substitution map.
+// according to the substitution map, in the walk order.
 func (d *DAWG[T]) SimilarItemValues(word string, subs map[rune]rune) [][]T {
-	items := d.SimilarItems(word, subs)
-	res := make([][]T, 0, len(items))
-	for _, vals := range items {
+	res := make([][]T, 0)
+	d.store.similar(word, subs, func(_ string, vals []T) {
 		cp := make([]T, len(vals))
 		copy(cp, vals)
 		res = append(res, cp)
-	}
+	})
 	return res
 }
 
-// SimilarKeys returns words similar to the given one using the substitution map.
+// SimilarKeys returns words similar to the given one using the substitution
+// map, in the walk order.
 func (d *DAWG[T]) SimilarKeys(word string, subs map[rune]rune) []string {
-	items := d.SimilarItems(word, subs)
-	res := make([]string, 0, len(items))
-	for k := range items {
-		res = append(res, k)
-	}
-	return res
-}
-
-// generateVariants returns all variants of word by replacing characters using
-// substitutions from the map. The original word is always included.
-func generateVariants(word string, subs map[rune]rune) []string {
-	variants := map[string]struct{}{word: {}}
-	runes := []rune(word)
-	for i, r := range runes {
-		if sub, ok := subs[r]; ok {
-			keys := make([]string, 0, len(variants))
-			for k := range variants {
-				keys = append(keys, k)
-			}
-			for _, v := range keys {
-				rr := []rune(v)
-				rr[i] = sub
-				variants[string(rr)] = struct{}{}
-			}
-		}
-	}
-	res := make([]string, 0, len(variants))
-	for v := range variants {
-		res = append(res, v)
-	}
+	res := make([]string, 0)
+	d.store.similar(word, subs, func(key string, _ []T) {
+		res = append(res, key)
+	})
 	return res
 }
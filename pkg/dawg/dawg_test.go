@@ -0,0 +1,207 @@
+package dawg
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"testing"
+)
+
+var testData = map[string][]int{
+	"ёж":      {1},
+	"ежа":     {2},
+	"еж":      {3},
+	"ежи":     {4, 5},
+	"ель":     {6},
+	"ели":     {7},
+	"ёлка":    {8},
+	"":        {9},
+	"колка":   {10},
+	"ёлочка":  {11},
+	"полочка": {12},
+}
+
+func TestDAFSA(t *testing.T) {
+	d := New(testData)
+	for k, v := range testData {
+		if got := d.Items(k); !reflect.DeepEqual(got, v) {
+			t.Errorf("Items(%q) = %v, want %v", k, got, v)
+		}
+	}
+	if got := d.Items("ежик"); got != nil {
+		t.Errorf("Items(ежик) = %v, want nil", got)
+	}
+	if !reflect.DeepEqual(d.Data(), testData) {
+		t.Errorf("Data() = %v, want %v", d.Data(), testData)
+	}
+
+	if got, want := d.Prefixes("ежиха"), []string{"еж", "ежи"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Prefixes = %v, want %v", got, want)
+	}
+	if d.IsPrefixed("жи") {
+		t.Error("IsPrefixed(жи) = true")
+	}
+
+	var keys []string
+	d.Walk("е", func(key string, _ []int) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if want := []string{"еж", "ежа", "ежи", "ели", "ель"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Walk = %v, want %v", keys, want)
+	}
+	keys = keys[:0]
+	for key := range d.All("е") {
+		if keys = append(keys, key); len(keys) == 3 {
+			break
+		}
+	}
+	if want := []string{"еж", "ежа", "ежи"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("All = %v, want %v", keys, want)
+	}
+
+	subs := map[rune]rune{'е': 'ё'}
+	got := d.SimilarKeys("елочка", subs)
+	if want := []string{"ёлочка"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SimilarKeys = %v, want %v", got, want)
+	}
+	got = d.SimilarKeys("еж", subs)
+	sort.Strings(got)
+	if want := []string{"еж", "ёж"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SimilarKeys = %v, want %v", got, want)
+	}
+}
+
+func TestDAFSAIsMinimal(t *testing.T) {
+	d := New(map[string][]int{"tap": {1}, "taps": {2}, "top": {3}, "tops": {4}})
+	// root -t-> 1 -a,o-> 2 -p-> 3 -s-> 4; a trie would need 8 nodes.
+	if n := len(d.store.(*dafsa[int]).final); n != 5 {
+		t.Errorf("nodes = %d, want 5", n)
+	}
+	if got := d.Items("tops"); !reflect.DeepEqual(got, []int{4}) {
+		t.Errorf("Items(tops) = %v, want [4]", got)
+	}
+}
+
+func TestRecordDAWGRoundTrip(t *testing.T) {
+	data := map[string][]WordForm{}
+	rnd := rand.New(rand.NewSource(1))
+	alphabet := []rune("аеёжилкопрст")
+	for i := 0; i < 3000; i++ {
+		word := make([]rune, 1+rnd.Intn(8))
+		for j := range word {
+			word[j] = alphabet[rnd.Intn(len(alphabet))]
+		}
+		data[string(word)] = append(data[string(word)], WordForm{ParadigmID: uint16(rnd.Intn(5000)), FormIndex: uint16(rnd.Intn(300))})
+	}
+	path := filepath.Join(t.TempDir(), "words.dawg")
+	if err := NewWordsDawg(data).Save(path); err != nil {
+		t.Fatal(err)
+	}
+	d, err := LoadWordsDawg(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := New(data)
+	for k, v := range data {
+		got := d.Items(k)
+		if len(got) != len(v) {
+			t.Fatalf("Items(%q) = %v, want %v", k, got, v)
+		}
+		if got, want := d.Prefixes(k+"ж"), want.Prefixes(k+"ж"); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Prefixes(%q) = %v, want %v", k, got, want)
+		}
+	}
+	for _, k := range []string{"", "я", "ёжик"} {
+		if d.Items(k) != nil && data[k] == nil {
+			t.Errorf("Items(%q) = %v, want nil", k, d.Items(k))
+		}
+	}
+	if got := d.Data(); len(got) != len(data) {
+		t.Errorf("Data() has %d keys, want %d", len(got), len(data))
+	}
+
+	var gotKeys, wantKeys []string
+	d.Walk("е", func(key string, _ []WordForm) bool { gotKeys = append(gotKeys, key); return true })
+	want.Walk("е", func(key string, _ []WordForm) bool { wantKeys = append(wantKeys, key); return true })
+	if !reflect.DeepEqual(gotKeys, wantKeys) {
+		t.Errorf("Walk = %v, want %v", gotKeys, wantKeys)
+	}
+	subs := map[rune]rune{'е': 'ё'}
+	for k := range data {
+		if got, want := d.SimilarKeys(k, subs), want.SimilarKeys(k, subs); !reflect.DeepEqual(got, want) {
+			t.Fatalf("SimilarKeys(%q) = %v, want %v", k, got, want)
+		}
+	}
+}
+
+func TestEmptyRecordDAWG(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.dawg")
+	if err := NewPredictionSuffixesDAWG(nil).Save(path); err != nil {
+		t.Fatal(err)
+	}
+	d, err := LoadPredictionSuffixesDAWG(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Data()) != 0 || d.Items("а") != nil {
+		t.Errorf("empty DAWG has data: %v", d.Data())
+	}
+}
+
+func TestConditionalProbDistRoundTrip(t *testing.T) {
+	probs := map[string]map[string]float64{
+		"мамы": {"NOUN,anim,femn plur,nomn": 0.6, "NOUN,anim,femn sing,gent": 0.4},
+		"мам":  {"NOUN,anim,femn plur,gent": 0.75, "NOUN,anim,femn plur,accs": 0.25},
+	}
+	path := filepath.Join(t.TempDir(), "p_t_given_w.intdawg")
+	if err := WriteConditionalProbDist(path, probs); err != nil {
+		t.Fatal(err)
+	}
+	d, err := LoadConditionalProbDist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for word, want := range probs {
+		if got := d.Probs(word); !reflect.DeepEqual(got, want) {
+			t.Errorf("Probs(%q) = %v, want %v", word, got, want)
+		}
+		for tag, p := range want {
+			if got := d.Prob(word, tag); got != p {
+				t.Errorf("Prob(%q, %q) = %v, want %v", word, tag, got, p)
+			}
+		}
+	}
+	if got := d.Probs("ма"); len(got) != 0 {
+		t.Errorf("Probs(ма) = %v, want empty", got)
+	}
+	if got, want := d.dawg.Keys("мамы:"), []string{"мамы:NOUN,anim,femn plur,nomn", "мамы:NOUN,anim,femn sing,gent"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys = %v, want %v", got, want)
+	}
+}
+
+func TestCorruptTable(t *testing.T) {
+	data := map[string][]WordForm{"а": {{1, 0}}, "бб": {{2, 0}, {2, 1}}, "в": {{3, 0}}}
+	buf := EncodeTable(data, WordFormCodec{})
+	if _, err := NewFromTable(buf, WordFormCodec{}); err != nil {
+		t.Fatal(err)
+	}
+	corrupt := func(name string, off int, v uint32) {
+		t.Helper()
+		b := slices.Clone(buf)
+		binary.LittleEndian.PutUint32(b[off:], v)
+		if _, err := NewFromTable(b, WordFormCodec{}); err == nil {
+			t.Errorf("%s: NewFromTable succeeded", name)
+		}
+	}
+	// offsets follow the header: 4 key offsets, then 4 value offsets
+	corrupt("key offset", tableHeaderSize+4, 100)
+	corrupt("key offsets order", tableHeaderSize+8, 1)
+	corrupt("value offset", tableHeaderSize+16+8, 0)
+	if _, err := NewFromTable(buf[:len(buf)-1], WordFormCodec{}); err == nil {
+		t.Error("NewFromTable succeeded on truncated table")
+	}
+}
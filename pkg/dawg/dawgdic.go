@@ -0,0 +1,190 @@
+package dawg
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// The following constants and helpers mirror logic from the “dawg“
+// Python package used by pymorphy2.
+const (
+	precisionMask = 0xFFFFFFFF
+	isLeafBit     = 1 << 31
+	hasLeafBit    = 1 << 8
+	extensionBit  = 1 << 9
+)
+
+// root is the index of the dictionary root unit.
+const root = 0
+
+func hasLeaf(base uint32) bool     { return base&hasLeafBit != 0 }
+func unitValue(base uint32) uint32 { return base &^ uint32(isLeafBit) & precisionMask }
+func unitLabel(base uint32) uint32 { return base & (isLeafBit | 0xFF) }
+func unitOffset(base uint32) uint32 {
+	return ((base >> 10) << ((base & extensionBit) >> 6)) & precisionMask
+}
+
+// dictionary is a reader for dawgdic double-array dictionaries used by the
+// "DAWG" and "dawg-python" packages.
+type dictionary struct {
+	units []uint32
+}
+
+// readDictionary reads dictionary units: uint32 size followed by units.
+func readDictionary(r io.Reader) (*dictionary, error) {
+	var size uint32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return nil, err
+	}
+	units := make([]uint32, size)
+	if err := binary.Read(r, binary.LittleEndian, units); err != nil {
+		return nil, err
+	}
+	return &dictionary{units: units}, nil
+}
+
+func (d *dictionary) hasValue(index uint32) bool { return hasLeaf(d.units[index]) }
+
+func (d *dictionary) value(index uint32) uint32 {
+	off := unitOffset(d.units[index])
+	return unitValue(d.units[(index^off)&precisionMask])
+}
+
+func (d *dictionary) followChar(label byte, index uint32) (uint32, bool) {
+	off := unitOffset(d.units[index])
+	next := (index ^ off ^ uint32(label)) & precisionMask
+	if int(next) >= len(d.units) || unitLabel(d.units[next]) != uint32(label) {
+		return 0, false
+	}
+	return next, true
+}
+
+func (d *dictionary) followBytes(bs []byte, index uint32) (uint32, bool) {
+	for _, b := range bs {
+		var ok bool
+		index, ok = d.followChar(b, index)
+		if !ok {
+			return 0, false
+		}
+	}
+	return index, true
+}
+
+func (d *dictionary) find(bs []byte) (uint32, bool) {
+	index, ok := d.followBytes(bs, root)
+	if !ok || !d.hasValue(index) {
+		return 0, false
+	}
+	return d.value(index), true
+}
+
+// guide stores first child and next sibling labels of every unit.
+type guide struct {
+	units []byte
+}
+
+// readGuide reads guide units: uint32 size followed by size*2 bytes.
+func readGuide(r io.Reader) (*guide, error) {
+	var size uint32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return nil, err
+	}
+	units := make([]byte, 2*int(size))
+	if _, err := io.ReadFull(r, units); err != nil {
+		return nil, err
+	}
+	return &guide{units: units}, nil
+}
+
+func (g *guide) child(index uint32) byte   { return g.units[2*index] }
+func (g *guide) sibling(index uint32) byte { return g.units[2*index+1] }
+
+// completer enumerates keys stored below a dictionary unit in byte order.
+type completer struct {
+	dct        *dictionary
+	guide      *guide
+	key        []byte
+	indexStack []uint32
+	lastIndex  uint32
+}
+
+func newCompleter(dct *dictionary, g *guide) *completer {
+	return &completer{dct: dct, guide: g}
+}
+
+// start begins enumeration of completions of prefix ending at index.
+func (c *completer) start(index uint32, prefix []byte) {
+	c.key = append(c.key[:0], prefix...)
+	c.indexStack = c.indexStack[:0]
+	if len(c.guide.units) > 0 {
+		c.indexStack = append(c.indexStack, index)
+		c.lastIndex = root
+	}
+}
+
+// next moves to the next key; the key is available in c.key.
+func (c *completer) next() bool {
+	if len(c.indexStack) == 0 {
+		return false
+	}
+	index := c.indexStack[len(c.indexStack)-1]
+	if c.lastIndex != root {
+		if childLabel := c.guide.child(index); childLabel != 0 {
+			var ok bool
+			if index, ok = c.follow(childLabel, index); !ok {
+				return false
+			}
+		} else {
+			for {
+				siblingLabel := c.guide.sibling(index)
+				if len(c.key) > 0 {
+					c.key = c.key[:len(c.key)-1]
+				}
+				c.indexStack = c.indexStack[:len(c.indexStack)-1]
+				if len(c.indexStack) == 0 {
+					return false
+				}
+				index = c.indexStack[len(c.indexStack)-1]
+				if siblingLabel != 0 {
+					var ok bool
+					if index, ok = c.follow(siblingLabel, index); !ok {
+						return false
+					}
+					break
+				}
+			}
+		}
+	}
+	return c.findTerminal(index)
+}
+
+// value returns the value of the current key.
+func (c *completer) value() uint32 { return c.dct.value(c.lastIndex) }
+
+func (c *completer) follow(label byte, index uint32) (uint32, bool) {
+	next, ok := c.dct.followChar(label, index)
+	if !ok {
+		return 0, false
+	}
+	c.key = append(c.key, label)
+	c.indexStack = append(c.indexStack, next)
+	return next, true
+}
+
+func (c *completer) findTerminal(index uint32) bool {
+	for !c.dct.hasValue(index) {
+		label := c.guide.child(index)
+		if label == 0 {
+			return false
+		}
+		next, ok := c.dct.followChar(label, index)
+		if !ok {
+			return false
+		}
+		index = next
+		c.key = append(c.key, label)
+		c.indexStack = append(c.indexStack, index)
+	}
+	c.lastIndex = index
+	return true
+}
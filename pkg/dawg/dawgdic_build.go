@@ -0,0 +1,508 @@
+package dawg
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// buildDawgdic builds a dawgdic dictionary and guide from keys sorted in
+// ascending byte order. Keys must not contain zero bytes; values must fit in
+// 31 bits. The builders below follow DawgBuilder, DictionaryBuilder and
+// GuideBuilder of dawgdic, so the units are the ones the "DAWG" Python
+// package writes for the same keys.
+func buildDawgdic(keys []string, values []uint32) (*dictionary, *guide, error) {
+	b := newDawgBuilder()
+	for i, k := range keys {
+		if strings.IndexByte(k, 0) >= 0 {
+			return nil, nil, fmt.Errorf("dawg: key %q contains zero byte", k)
+		}
+		if values[i]&isLeafBit != 0 {
+			return nil, nil, fmt.Errorf("dawg: value %d of key %q is too large", values[i], k)
+		}
+		if i > 0 && k < keys[i-1] {
+			return nil, nil, fmt.Errorf("dawg: keys are not sorted")
+		}
+		b.insert(k, values[i])
+	}
+	dg := b.finish()
+
+	db := &dictionaryBuilder{dawg: dg, linkTable: map[uint32]uint32{}}
+	units, err := db.build()
+	if err != nil {
+		return nil, nil, err
+	}
+	d := &dictionary{units: units}
+	gb := &guideBuilder{dawg: dg, dct: d}
+	g, err := gb.build()
+	if err != nil {
+		return nil, nil, err
+	}
+	return d, &guide{units: g}, nil
+}
+
+// dawgUnit is a transition of the automaton under construction. The child
+// of a leaf unit (label 0) holds the value of the key.
+type dawgUnit struct {
+	child, sibling uint32
+	label          byte
+	isState        bool
+	hasSibling     bool
+}
+
+func (u *dawgUnit) base() uint32 {
+	var sib uint32
+	if u.hasSibling {
+		sib = 1
+	}
+	if u.label == 0 {
+		return u.child<<1 | sib
+	}
+	var state uint32
+	if u.isState {
+		state = 2
+	}
+	return u.child<<2 | state | sib
+}
+
+// dawgBuilder is dawgdic::DawgBuilder: transitions of the last inserted key
+// stay unfixed until a key diverges from it; then equal sibling chains are
+// merged and written to the pools.
+type dawgBuilder struct {
+	units     []dawgUnit
+	unfixed   []uint32
+	basePool  []uint32
+	labelPool []byte
+	flagPool  []bool
+	states    map[string]uint32
+}
+
+func newDawgBuilder() *dawgBuilder {
+	return &dawgBuilder{
+		units:     []dawgUnit{{label: 0xFF}},
+		unfixed:   []uint32{0},
+		basePool:  []uint32{0},
+		labelPool: []byte{0},
+		flagPool:  []bool{false},
+		states:    map[string]uint32{},
+	}
+}
+
+// insert adds key with value; keys are terminated by a leaf transition with
+// label 0.
+func (b *dawgBuilder) insert(key string, value uint32) {
+	index := uint32(0)
+	pos := 0
+	for ; pos <= len(key); pos++ {
+		child := b.units[index].child
+		if child == 0 {
+			break
+		}
+		var label byte
+		if pos < len(key) {
+			label = key[pos]
+		}
+		if label > b.units[child].label {
+			b.units[child].hasSibling = true
+			b.fixUnits(child)
+			break
+		}
+		index = child
+	}
+	for ; pos <= len(key); pos++ {
+		var label byte
+		if pos < len(key) {
+			label = key[pos]
+		}
+		child := uint32(len(b.units))
+		b.units = append(b.units, dawgUnit{
+			sibling: b.units[index].child,
+			label:   label,
+			isState: b.units[index].child == 0,
+		})
+		b.units[index].child = child
+		b.unfixed = append(b.unfixed, child)
+		index = child
+	}
+	b.units[index].child = value
+}
+
+func (b *dawgBuilder) finish() *dawgPools {
+	b.fixUnits(0)
+	b.basePool[0] = b.units[0].base()
+	b.labelPool[0] = b.units[0].label
+	return &dawgPools{base: b.basePool, label: b.labelPool, merging: b.flagPool}
+}
+
+// fixUnits moves unfixed transitions above index to the pools, reusing an
+// equal sibling chain when there is one.
+func (b *dawgBuilder) fixUnits(index uint32) {
+	for b.unfixed[len(b.unfixed)-1] != index {
+		unfixed := b.unfixed[len(b.unfixed)-1]
+		b.unfixed = b.unfixed[:len(b.unfixed)-1]
+
+		var chain []uint32
+		var key []byte
+		for i := unfixed; i != 0; i = b.units[i].sibling {
+			chain = append(chain, i)
+			key = binary.LittleEndian.AppendUint32(key, b.units[i].base())
+			key = append(key, b.units[i].label)
+		}
+		matched, ok := b.states[string(key)]
+		if ok {
+			b.flagPool[matched] = true
+		} else {
+			// siblings are linked from the largest label, the pool keeps
+			// them in ascending order
+			for i := len(chain) - 1; i >= 0; i-- {
+				u := &b.units[chain[i]]
+				b.basePool = append(b.basePool, u.base())
+				b.labelPool = append(b.labelPool, u.label)
+				b.flagPool = append(b.flagPool, false)
+			}
+			matched = uint32(len(b.basePool) - len(chain))
+			b.states[string(key)] = matched
+		}
+		b.units[b.unfixed[len(b.unfixed)-1]].child = matched
+	}
+	b.unfixed = b.unfixed[:len(b.unfixed)-1]
+}
+
+// dawgPools is dawgdic::Dawg, a minimal automaton stored as transitions.
+// Siblings are adjacent; a merging state is reached by several transitions.
+type dawgPools struct {
+	base    []uint32
+	label   []byte
+	merging []bool
+}
+
+func (d *dawgPools) size() int                   { return len(d.base) }
+func (d *dawgPools) child(index uint32) uint32   { return d.base[index] >> 2 }
+func (d *dawgPools) value(index uint32) uint32   { return d.base[index] >> 1 }
+func (d *dawgPools) isLeaf(index uint32) bool    { return d.label[index] == 0 }
+func (d *dawgPools) isMerging(index uint32) bool { return d.merging[index] }
+
+func (d *dawgPools) sibling(index uint32) uint32 {
+	if d.base[index]&1 != 0 {
+		return index + 1
+	}
+	return 0
+}
+
+// Layout parameters of dawgdic::DictionaryBuilder.
+const (
+	offsetMax          = 1 << 21
+	upperMask          = ^uint32(offsetMax - 1)
+	lowerMask          = 0xFF
+	blockSize          = 256
+	numOfUnfixedBlocks = 16
+)
+
+// setOffset stores offset in base keeping its flags and label.
+func setOffset(base, offset uint32) (uint32, bool) {
+	if offset >= offsetMax<<8 {
+		return 0, false
+	}
+	base &= isLeafBit | hasLeafBit | 0xFF
+	if offset < offsetMax {
+		return base | offset<<10, true
+	}
+	return base | offset<<2 | extensionBit, true
+}
+
+// dictionaryBuilder is dawgdic::DictionaryBuilder. Units of the last 16
+// blocks are unfixed and linked into a circular list of free units; when a
+// block leaves that window, its free units are fixed with labels which no
+// transition can match.
+type dictionaryBuilder struct {
+	dawg         *dawgPools
+	units        []uint32
+	isFixed      []bool
+	isUsed       []bool // unit index is the offset of some node
+	next, prev   []uint32
+	linkTable    map[uint32]uint32
+	labels       []byte
+	unfixedIndex uint32
+}
+
+func (db *dictionaryBuilder) build() ([]uint32, error) {
+	db.reserveUnit(0)
+	db.isUsed[0] = true
+	db.units[0], _ = setOffset(db.units[0], 1)
+	db.units[0] &^= 0xFF
+	if db.dawg.size() > 1 {
+		if !db.buildNode(root, 0) {
+			return nil, fmt.Errorf("dawg: too many units")
+		}
+	}
+	db.fixAllBlocks()
+	return db.units, nil
+}
+
+func (db *dictionaryBuilder) buildNode(dawgIndex, dicIndex uint32) bool {
+	dg := db.dawg
+	if dg.isLeaf(dawgIndex) {
+		return true
+	}
+	child := dg.child(dawgIndex)
+	if dg.isMerging(child) {
+		if offset := db.linkTable[child]; offset != 0 {
+			offset ^= dicIndex
+			if offset&upperMask == 0 || offset&lowerMask == 0 {
+				if dg.isLeaf(child) {
+					db.units[dicIndex] |= hasLeafBit
+				}
+				db.units[dicIndex], _ = setOffset(db.units[dicIndex], offset)
+				return true
+			}
+		}
+	}
+
+	offset, ok := db.arrangeChildNodes(dawgIndex, dicIndex)
+	if !ok {
+		return false
+	}
+	if dg.isMerging(child) {
+		db.linkTable[child] = offset
+	}
+	for ; child != 0; child = dg.sibling(child) {
+		if !db.buildNode(child, offset^uint32(dg.label[child])) {
+			return false
+		}
+	}
+	return true
+}
+
+func (db *dictionaryBuilder) arrangeChildNodes(dawgIndex, dicIndex uint32) (uint32, bool) {
+	dg := db.dawg
+	db.labels = db.labels[:0]
+	for child := dg.child(dawgIndex); child != 0; child = dg.sibling(child) {
+		db.labels = append(db.labels, dg.label[child])
+	}
+
+	offset := db.findGoodOffset(dicIndex)
+	base, ok := setOffset(db.units[dicIndex], dicIndex^offset)
+	if !ok {
+		return 0, false
+	}
+	db.units[dicIndex] = base
+
+	child := dg.child(dawgIndex)
+	for _, label := range db.labels {
+		dicChild := offset ^ uint32(label)
+		db.reserveUnit(dicChild)
+		if dg.isLeaf(child) {
+			db.units[dicIndex] |= hasLeafBit
+			db.units[dicChild] = dg.value(child) | isLeafBit
+		} else {
+			db.units[dicChild] = db.units[dicChild]&^0xFF | uint32(label)
+		}
+		child = dg.sibling(child)
+	}
+	db.isUsed[offset] = true
+	return offset, true
+}
+
+func (db *dictionaryBuilder) findGoodOffset(index uint32) uint32 {
+	if int(db.unfixedIndex) >= len(db.units) {
+		return uint32(len(db.units)) | index&0xFF
+	}
+	unfixed := db.unfixedIndex
+	for {
+		offset := unfixed ^ uint32(db.labels[0])
+		if db.isGoodOffset(index, offset) {
+			return offset
+		}
+		unfixed = db.next[unfixed]
+		if unfixed == db.unfixedIndex {
+			break
+		}
+	}
+	return uint32(len(db.units)) | index&0xFF
+}
+
+func (db *dictionaryBuilder) isGoodOffset(index, offset uint32) bool {
+	if db.isUsed[offset] {
+		return false
+	}
+	relative := index ^ offset
+	if relative&lowerMask != 0 && relative&upperMask != 0 {
+		return false
+	}
+	for _, label := range db.labels[1:] {
+		if db.isFixed[offset^uint32(label)] {
+			return false
+		}
+	}
+	return true
+}
+
+// reserveUnit fixes unit index and removes it from the list of free units.
+func (db *dictionaryBuilder) reserveUnit(index uint32) {
+	if int(index) >= len(db.units) {
+		db.expandDictionary()
+	}
+	if index == db.unfixedIndex {
+		db.unfixedIndex = db.next[index]
+		if db.unfixedIndex == index {
+			db.unfixedIndex = uint32(len(db.units))
+		}
+	}
+	db.next[db.prev[index]] = db.next[index]
+	db.prev[db.next[index]] = db.prev[index]
+	db.isFixed[index] = true
+}
+
+// expandDictionary appends a block of free units, fixing the block which
+// leaves the window of unfixed blocks.
+func (db *dictionaryBuilder) expandDictionary() {
+	src := uint32(len(db.units))
+	srcBlocks := src / blockSize
+	dest := src + blockSize
+	if srcBlocks+1 > numOfUnfixedBlocks {
+		db.fixBlock(srcBlocks - numOfUnfixedBlocks)
+	}
+
+	db.units = append(db.units, make([]uint32, blockSize)...)
+	db.next = append(db.next, make([]uint32, blockSize)...)
+	db.prev = append(db.prev, make([]uint32, blockSize)...)
+	db.isFixed = append(db.isFixed, make([]bool, blockSize)...)
+	db.isUsed = append(db.isUsed, make([]bool, blockSize)...)
+
+	for i := src + 1; i < dest; i++ {
+		db.next[i-1] = i
+		db.prev[i] = i - 1
+	}
+	db.prev[src] = dest - 1
+	db.next[dest-1] = src
+
+	db.prev[src] = db.prev[db.unfixedIndex]
+	db.next[dest-1] = db.unfixedIndex
+	db.next[db.prev[db.unfixedIndex]] = src
+	db.prev[db.unfixedIndex] = dest - 1
+}
+
+func (db *dictionaryBuilder) fixAllBlocks() {
+	blocks := uint32(len(db.units)) / blockSize
+	begin := uint32(0)
+	if blocks > numOfUnfixedBlocks {
+		begin = blocks - numOfUnfixedBlocks
+	}
+	for id := begin; id < blocks; id++ {
+		db.fixBlock(id)
+	}
+}
+
+// fixBlock reserves the free units of a block. Their labels are chosen so
+// that following any label from the unused offset of the block does not
+// reach them: index^unusedOffset differs from the label it would need.
+func (db *dictionaryBuilder) fixBlock(id uint32) {
+	begin := id * blockSize
+	end := begin + blockSize
+	unusedOffset := uint32(0)
+	for offset := begin; offset < end; offset++ {
+		if !db.isUsed[offset] {
+			unusedOffset = offset
+			break
+		}
+	}
+	for index := begin; index < end; index++ {
+		if !db.isFixed[index] {
+			db.reserveUnit(index)
+			db.units[index] = db.units[index]&^0xFF | (index^unusedOffset)&0xFF
+		}
+	}
+}
+
+// guideBuilder is dawgdic::GuideBuilder: for every unit it stores the label
+// of the first child and of the next sibling, so completers can enumerate
+// keys in order.
+type guideBuilder struct {
+	dawg    *dawgPools
+	dct     *dictionary
+	units   []byte
+	isFixed []bool
+}
+
+func (gb *guideBuilder) build() ([]byte, error) {
+	if gb.dawg.size() <= 1 {
+		return nil, nil
+	}
+	gb.units = make([]byte, 2*len(gb.dct.units))
+	gb.isFixed = make([]bool, len(gb.dct.units))
+	if !gb.buildNode(root, root) {
+		return nil, fmt.Errorf("dawg: can't build guide")
+	}
+	return gb.units, nil
+}
+
+func (gb *guideBuilder) buildNode(dawgIndex, dicIndex uint32) bool {
+	if gb.isFixed[dicIndex] {
+		return true
+	}
+	gb.isFixed[dicIndex] = true
+
+	dg := gb.dawg
+	child := dg.child(dawgIndex)
+	if dg.label[child] == 0 {
+		if child = dg.sibling(child); child == 0 {
+			return true
+		}
+	}
+	gb.units[2*dicIndex] = dg.label[child]
+	for child != 0 {
+		dicChild, ok := gb.dct.followChar(dg.label[child], dicIndex)
+		if !ok || !gb.buildNode(child, dicChild) {
+			return false
+		}
+		sibling := dg.sibling(child)
+		if sibling != 0 {
+			gb.units[2*dicChild+1] = dg.label[sibling]
+		}
+		child = sibling
+	}
+	return true
+}
+
+// writeDawgdic writes dictionary followed by guide to path, the layout of
+// CompletionDAWG based files.
+func writeDawgdic(path string, d *dictionary, g *guide) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := d.write(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := g.write(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// write stores dictionary units: uint32 size followed by units.
+func (d *dictionary) write(w io.Writer) error {
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(d.units))); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, d.units)
+}
+
+// write stores guide units: uint32 size followed by size*2 bytes.
+func (g *guide) write(w io.Writer) error {
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(g.units)/2)); err != nil {
+		return err
+	}
+	_, err := w.Write(g.units)
+	return err
+}
@@ -0,0 +1,111 @@
+package dawg
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+)
+
+// IntCompletionDAWG maps string keys to non-negative integers and supports
+// iteration over keys with a given prefix. Files are compatible with
+// IntCompletionDAWG of the "DAWG" Python package.
+type IntCompletionDAWG struct {
+	dct   *dictionary
+	guide *guide
+}
+
+// NewIntCompletionDAWG builds IntCompletionDAWG from data. Keys must not
+// contain zero bytes; values must be in [0, 2^31).
+func NewIntCompletionDAWG(data map[string]int) (*IntCompletionDAWG, error) {
+	keys := make([]string, 0, len(data))
+	for k, v := range data {
+		if v < 0 || v > math.MaxInt32 {
+			return nil, fmt.Errorf("dawg: value %d of key %q is out of range", v, k)
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values := make([]uint32, len(keys))
+	for i, k := range keys {
+		values[i] = uint32(data[k])
+	}
+	dct, g, err := buildDawgdic(keys, values)
+	if err != nil {
+		return nil, err
+	}
+	return &IntCompletionDAWG{dct: dct, guide: g}, nil
+}
+
+// LoadIntCompletionDAWG reads IntCompletionDAWG from path.
+func LoadIntCompletionDAWG(path string) (*IntCompletionDAWG, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	dct, err := readDictionary(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	g, err := readGuide(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &IntCompletionDAWG{dct: dct, guide: g}, nil
+}
+
+// Save writes the DAWG to path.
+func (d *IntCompletionDAWG) Save(path string) error {
+	return writeDawgdic(path, d.dct, d.guide)
+}
+
+// Get returns value of key.
+func (d *IntCompletionDAWG) Get(key string) (int, bool) {
+	v, ok := d.dct.find([]byte(key))
+	return int(v), ok
+}
+
+// Has reports whether key is stored in the DAWG.
+func (d *IntCompletionDAWG) Has(key string) bool {
+	_, ok := d.dct.find([]byte(key))
+	return ok
+}
+
+// Walk calls fn for every key starting with prefix and its value, in byte
+// order of keys, until fn returns false.
+func (d *IntCompletionDAWG) Walk(prefix string, fn func(key string, value int) bool) {
+	index, ok := d.dct.followBytes([]byte(prefix), root)
+	if !ok {
+		return
+	}
+	c := newCompleter(d.dct, d.guide)
+	c.start(index, []byte(prefix))
+	for c.next() {
+		if !fn(string(c.key), int(c.value())) {
+			return
+		}
+	}
+}
+
+// Keys returns keys starting with prefix in byte order.
+func (d *IntCompletionDAWG) Keys(prefix string) []string {
+	res := make([]string, 0)
+	d.Walk(prefix, func(key string, _ int) bool {
+		res = append(res, key)
+		return true
+	})
+	return res
+}
+
+// Items returns keys starting with prefix with their values.
+func (d *IntCompletionDAWG) Items(prefix string) map[string]int {
+	res := map[string]int{}
+	d.Walk(prefix, func(key string, value int) bool {
+		res[key] = value
+		return true
+	})
+	return res
+}
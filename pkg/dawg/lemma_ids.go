@@ -0,0 +1,58 @@
+package dawg
+
+import "encoding/binary"
+
+// LemmaRef links a paradigm of a normal form to the ID of the source lemma.
+type LemmaRef struct {
+	ParadigmID uint16
+	LemmaID    uint32
+}
+
+// LemmaIDsDAWG maps normal forms to source lemma IDs of their paradigms.
+type LemmaIDsDAWG struct {
+	*DAWG[LemmaRef]
+}
+
+// NewLemmaIDsDAWG creates a LemmaIDsDAWG from the data map.
+func NewLemmaIDsDAWG(data map[string][]LemmaRef) *LemmaIDsDAWG {
+	return &LemmaIDsDAWG{New[LemmaRef](data)}
+}
+
+// LoadLemmaIDsDAWG loads a LemmaIDsDAWG saved with Save.
+func LoadLemmaIDsDAWG(path string) (*LemmaIDsDAWG, error) {
+	d, err := LoadRecordDAWG[LemmaRef](path, lemmaRefRecordCodec{})
+	if err != nil {
+		return nil, err
+	}
+	return &LemmaIDsDAWG{d}, nil
+}
+
+// Save writes the DAWG to path as a RecordDAWG with ">HI" records.
+func (d *LemmaIDsDAWG) Save(path string) error {
+	return WriteRecordDAWG(path, d.Data(), lemmaRefRecordCodec{})
+}
+
+// Lookup returns IDs of lemmas with the normal form and paradigm. Homonyms
+// sharing a paradigm have several IDs.
+func (d *LemmaIDsDAWG) Lookup(normalForm string, paradigmID uint16) []uint32 {
+	var res []uint32
+	for _, ref := range d.Items(normalForm) {
+		if ref.ParadigmID == paradigmID {
+			res = append(res, ref.LemmaID)
+		}
+	}
+	return res
+}
+
+type lemmaRefRecordCodec struct{}
+
+func (lemmaRefRecordCodec) Size() int { return 6 }
+
+func (lemmaRefRecordCodec) Put(b []byte, v LemmaRef) {
+	binary.BigEndian.PutUint16(b[0:], v.ParadigmID)
+	binary.BigEndian.PutUint32(b[2:], v.LemmaID)
+}
+
+func (lemmaRefRecordCodec) Get(b []byte) LemmaRef {
+	return LemmaRef{ParadigmID: binary.BigEndian.Uint16(b[0:]), LemmaID: binary.BigEndian.Uint32(b[2:])}
+}
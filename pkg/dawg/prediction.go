@@ -1,5 +1,7 @@
 package dawg
 
+import "encoding/binary"
+
 // Prediction stores data used for suffix prediction.
 type Prediction struct {
 	Count      uint16
@@ -17,6 +19,70 @@ func NewPredictionSuffixesDAWG(data map[string][]Prediction) *PredictionSuffixes
 	return &PredictionSuffixesDAWG{New[Prediction](data)}
 }
 
+// NewPredictionSuffixesDAWGFromTable creates a PredictionSuffixesDAWG reading
+// data from a binary table produced by EncodeTable with PredictionCodec.
+func NewPredictionSuffixesDAWGFromTable(buf []byte) (*PredictionSuffixesDAWG, error) {
+	d, err := NewFromTable[Prediction](buf, PredictionCodec{})
+	if err != nil {
+		return nil, err
+	}
+	return &PredictionSuffixesDAWG{d}, nil
+}
+
+// PredictionCodec stores Prediction as three uint16 values.
+type PredictionCodec struct{}
+
+func (PredictionCodec) Size() int { return 6 }
+
+func (PredictionCodec) Put(b []byte, v Prediction) {
+	binary.LittleEndian.PutUint16(b[0:], v.Count)
+	binary.LittleEndian.PutUint16(b[2:], v.ParadigmID)
+	binary.LittleEndian.PutUint16(b[4:], v.FormIndex)
+}
+
+func (PredictionCodec) Get(b []byte) Prediction {
+	return Prediction{
+		Count:      binary.LittleEndian.Uint16(b[0:]),
+		ParadigmID: binary.LittleEndian.Uint16(b[2:]),
+		FormIndex:  binary.LittleEndian.Uint16(b[4:]),
+	}
+}
+
+// LoadPredictionSuffixesDAWG loads prediction-suffixes-N.dawg file of a
+// pymorphy2 dictionary.
+func LoadPredictionSuffixesDAWG(path string) (*PredictionSuffixesDAWG, error) {
+	d, err := LoadRecordDAWG[Prediction](path, predictionRecordCodec{})
+	if err != nil {
+		return nil, err
+	}
+	return &PredictionSuffixesDAWG{d}, nil
+}
+
+// Save writes the DAWG to path in the pymorphy2 prediction-suffixes-N.dawg format.
+func (d *PredictionSuffixesDAWG) Save(path string) error {
+	return WriteRecordDAWG(path, d.Data(), predictionRecordCodec{})
+}
+
+// predictionRecordCodec matches pymorphy2 PredictionSuffixesDAWG ">HHH"
+// record format.
+type predictionRecordCodec struct{}
+
+func (predictionRecordCodec) Size() int { return 6 }
+
+func (predictionRecordCodec) Put(b []byte, v Prediction) {
+	binary.BigEndian.PutUint16(b[0:], v.Count)
+	binary.BigEndian.PutUint16(b[2:], v.ParadigmID)
+	binary.BigEndian.PutUint16(b[4:], v.FormIndex)
+}
+
+func (predictionRecordCodec) Get(b []byte) Prediction {
+	return Prediction{
+		Count:      binary.BigEndian.Uint16(b[0:]),
+		ParadigmID: binary.BigEndian.Uint16(b[2:]),
+		FormIndex:  binary.BigEndian.Uint16(b[4:]),
+	}
+}
+
 // Lookup returns prediction records for a suffix.
 func (p *PredictionSuffixesDAWG) Lookup(suffix string) []Prediction {
 	return p.Items(suffix)
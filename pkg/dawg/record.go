@@ -0,0 +1,239 @@
+package dawg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// payloadSeparator separates keys from base64-encoded values in BytesDAWG.
+const payloadSeparator = 0x01
+
+// bytesDAWG is a reader for BytesDAWG/RecordDAWG files created by the "DAWG"
+// Python package. Every key is stored as key, separator and base64 of a
+// value; a key may have several values.
+type bytesDAWG struct {
+	dct   *dictionary
+	guide *guide
+}
+
+func loadBytesDAWG(path string) (*bytesDAWG, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	dct, err := readDictionary(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	g, err := readGuide(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &bytesDAWG{dct: dct, guide: g}, nil
+}
+
+// followKey returns index of the unit following key and separator.
+func (b *bytesDAWG) followKey(key string) (uint32, bool) {
+	index, ok := b.dct.followBytes([]byte(key), root)
+	if !ok {
+		return 0, false
+	}
+	return b.dct.followChar(payloadSeparator, index)
+}
+
+// payloads returns decoded values stored below index.
+func (b *bytesDAWG) payloads(index uint32) ([][]byte, error) {
+	var res [][]byte
+	c := newCompleter(b.dct, b.guide)
+	c.start(index, nil)
+	for c.next() {
+		v, err := decodePayload(c.key)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+// WriteRecordDAWG writes data to path as a RecordDAWG file readable by the
+// "DAWG" Python package and by LoadRecordDAWG. Values are encoded with codec.
+// As in the Python package, values of a key are read back ordered by their
+// base64 representation.
+func WriteRecordDAWG[T any](path string, data map[string][]T, codec Codec[T]) error {
+	keys := make([]string, 0, len(data))
+	buf := make([]byte, codec.Size())
+	for k, vals := range data {
+		if strings.IndexByte(k, payloadSeparator) >= 0 {
+			return fmt.Errorf("dawg: key %q contains payload separator", k)
+		}
+		for _, v := range vals {
+			codec.Put(buf, v)
+			keys = append(keys, k+string(rune(payloadSeparator))+encodePayload(buf))
+		}
+	}
+	sort.Strings(keys)
+	uniq := keys[:0]
+	for i, k := range keys {
+		if i == 0 || k != keys[i-1] {
+			uniq = append(uniq, k)
+		}
+	}
+	dct, g, err := buildDawgdic(uniq, make([]uint32, len(uniq)))
+	if err != nil {
+		return err
+	}
+	return writeDawgdic(path, dct, g)
+}
+
+// encodePayload matches Python binascii.b2a_base64 which ends with newline.
+func encodePayload(v []byte) string {
+	return base64.StdEncoding.EncodeToString(v) + "\n"
+}
+
+func decodePayload(b64 []byte) ([]byte, error) {
+	b64 = bytes.TrimRight(b64, "\n")
+	res := make([]byte, base64.StdEncoding.DecodedLen(len(b64)))
+	n, err := base64.StdEncoding.Decode(res, b64)
+	if err != nil {
+		return nil, err
+	}
+	return res[:n], nil
+}
+
+// recordStore reads DAWG values from a RecordDAWG decoding them with codec.
+type recordStore[T any] struct {
+	dawg  *bytesDAWG
+	codec Codec[T]
+}
+
+// LoadRecordDAWG loads a RecordDAWG file. Values are decoded with codec,
+// which must match the struct format used when the file was created.
+func LoadRecordDAWG[T any](path string, codec Codec[T]) (*DAWG[T], error) {
+	b, err := loadBytesDAWG(path)
+	if err != nil {
+		return nil, err
+	}
+	return &DAWG[T]{store: &recordStore[T]{dawg: b, codec: codec}}, nil
+}
+
+func (s *recordStore[T]) decode(payloads [][]byte) []T {
+	res := make([]T, 0, len(payloads))
+	for _, p := range payloads {
+		if len(p) < s.codec.Size() {
+			continue
+		}
+		res = append(res, s.codec.Get(p))
+	}
+	return res
+}
+
+func (s *recordStore[T]) get(key string) ([]T, bool) {
+	index, ok := s.dawg.followKey(key)
+	if !ok {
+		return nil, false
+	}
+	payloads, err := s.dawg.payloads(index)
+	if err != nil {
+		return nil, false
+	}
+	return s.decode(payloads), true
+}
+
+func (s *recordStore[T]) has(key string) bool {
+	_, ok := s.dawg.followKey(key)
+	return ok
+}
+
+func (s *recordStore[T]) items() map[string][]T {
+	res := map[string][]T{}
+	c := newCompleter(s.dawg.dct, s.dawg.guide)
+	c.start(root, nil)
+	for c.next() {
+		sep := bytes.IndexByte(c.key, payloadSeparator)
+		if sep < 0 {
+			continue
+		}
+		v, err := decodePayload(c.key[sep+1:])
+		if err != nil || len(v) < s.codec.Size() {
+			continue
+		}
+		key := string(c.key[:sep])
+		res[key] = append(res[key], s.codec.Get(v))
+	}
+	return res
+}
+
+func (s *recordStore[T]) walk(prefix string, fn func(key string, vals []T) bool) {
+	index, ok := s.dawg.dct.followBytes([]byte(prefix), root)
+	if !ok {
+		return
+	}
+	// Completions go in byte order and the separator sorts before any key
+	// byte, so values of a key are adjacent and keys are ordered.
+	var key string
+	var vals []T
+	c := newCompleter(s.dawg.dct, s.dawg.guide)
+	c.start(index, []byte(prefix))
+	for c.next() {
+		sep := bytes.IndexByte(c.key, payloadSeparator)
+		if sep < 0 {
+			continue
+		}
+		v, err := decodePayload(c.key[sep+1:])
+		if err != nil || len(v) < s.codec.Size() {
+			continue
+		}
+		if vals != nil && string(c.key[:sep]) != key {
+			if !fn(key, vals) {
+				return
+			}
+			vals = nil
+		}
+		key = string(c.key[:sep])
+		vals = append(vals, s.codec.Get(v))
+	}
+	if vals != nil {
+		fn(key, vals)
+	}
+}
+
+func (s *recordStore[T]) prefixes(word string) []string {
+	res := make([]string, 0)
+	dct := s.dawg.dct
+	index := uint32(root)
+	for i := 0; i < len(word); i++ {
+		var ok bool
+		if index, ok = dct.followChar(word[i], index); !ok {
+			break
+		}
+		if _, ok := dct.followChar(payloadSeparator, index); ok {
+			res = append(res, word[:i+1])
+		}
+	}
+	return res
+}
+
+func (s *recordStore[T]) similar(word string, subs map[rune]rune, fn func(key string, vals []T)) {
+	follow := func(index uint32, str string) (uint32, bool) {
+		return s.dawg.dct.followBytes([]byte(str), index)
+	}
+	similarWalk(word, subs, uint32(root), follow, func(key string, index uint32) {
+		index, ok := s.dawg.dct.followChar(payloadSeparator, index)
+		if !ok {
+			return
+		}
+		payloads, err := s.dawg.payloads(index)
+		if err != nil {
+			return
+		}
+		fn(key, s.decode(payloads))
+	})
+}
@@ -0,0 +1,207 @@
+package dawg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// Codec converts values to and from fixed-size binary records.
+type Codec[T any] interface {
+	Size() int
+	Put(b []byte, v T)
+	Get(b []byte) T
+}
+
+// Binary table layout (little-endian):
+//
+//	uint32 keys count N
+//	uint32 value record size
+//	uint32 key offsets [N+1]   (into key bytes)
+//	uint32 value offsets [N+1] (in records)
+//	key bytes, keys sorted in byte order
+//	value records
+const tableHeaderSize = 8
+
+// table is a store reading values directly from an encoded binary table.
+// The buffer is not copied, so it may point to memory-mapped file contents.
+type table[T any] struct {
+	codec   Codec[T]
+	n       int
+	keyOffs []byte
+	valOffs []byte
+	keys    []byte
+	vals    []byte
+}
+
+// EncodeTable serializes data to the binary table format.
+func EncodeTable[T any](data map[string][]T, codec Codec[T]) []byte {
+	keys := make([]string, 0, len(data))
+	keysLen, valsCount := 0, 0
+	for k, v := range data {
+		keys = append(keys, k)
+		keysLen += len(k)
+		valsCount += len(v)
+	}
+	sort.Strings(keys)
+	n := len(keys)
+	size := codec.Size()
+	buf := make([]byte, tableHeaderSize+8*(n+1)+keysLen+valsCount*size)
+	binary.LittleEndian.PutUint32(buf[0:], uint32(n))
+	binary.LittleEndian.PutUint32(buf[4:], uint32(size))
+	keyOffs := buf[tableHeaderSize:]
+	valOffs := keyOffs[4*(n+1):]
+	keyBytes := valOffs[4*(n+1):]
+	valBytes := keyBytes[keysLen:]
+	kpos, vpos := 0, 0
+	for i, k := range keys {
+		binary.LittleEndian.PutUint32(keyOffs[4*i:], uint32(kpos))
+		binary.LittleEndian.PutUint32(valOffs[4*i:], uint32(vpos))
+		kpos += copy(keyBytes[kpos:], k)
+		for _, v := range data[k] {
+			codec.Put(valBytes[vpos*size:], v)
+			vpos++
+		}
+	}
+	binary.LittleEndian.PutUint32(keyOffs[4*n:], uint32(kpos))
+	binary.LittleEndian.PutUint32(valOffs[4*n:], uint32(vpos))
+	return buf
+}
+
+// NewFromTable creates a DAWG reading data from an encoded binary table
+// without decoding it. The buffer must not be modified afterwards.
+func NewFromTable[T any](buf []byte, codec Codec[T]) (*DAWG[T], error) {
+	t, err := openTable(buf, codec)
+	if err != nil {
+		return nil, err
+	}
+	return &DAWG[T]{store: t}, nil
+}
+
+func openTable[T any](buf []byte, codec Codec[T]) (*table[T], error) {
+	if len(buf) < tableHeaderSize {
+		return nil, fmt.Errorf("dawg: table is truncated")
+	}
+	n := int(binary.LittleEndian.Uint32(buf[0:]))
+	size := int(binary.LittleEndian.Uint32(buf[4:]))
+	if size != codec.Size() {
+		return nil, fmt.Errorf("dawg: table record size is %d, expected %d", size, codec.Size())
+	}
+	rest := buf[tableHeaderSize:]
+	if len(rest) < 8*(n+1) {
+		return nil, fmt.Errorf("dawg: table is truncated")
+	}
+	t := &table[T]{codec: codec, n: n, keyOffs: rest[:4*(n+1)], valOffs: rest[4*(n+1) : 8*(n+1)]}
+	rest = rest[8*(n+1):]
+	keysLen := int(binary.LittleEndian.Uint32(t.keyOffs[4*n:]))
+	valsLen := int(binary.LittleEndian.Uint32(t.valOffs[4*n:])) * size
+	if len(rest) != keysLen+valsLen {
+		return nil, fmt.Errorf("dawg: table size mismatch")
+	}
+	// the last offsets are bounded above, so non-decreasing offsets are
+	// within bounds
+	if !offsetsAreSorted(t.keyOffs, n) || !offsetsAreSorted(t.valOffs, n) {
+		return nil, fmt.Errorf("dawg: table offsets are corrupt")
+	}
+	t.keys = rest[:keysLen]
+	t.vals = rest[keysLen:]
+	return t, nil
+}
+
+// offsetsAreSorted reports whether n+1 offsets in offs are non-decreasing.
+func offsetsAreSorted(offs []byte, n int) bool {
+	prev := uint32(0)
+	for i := 0; i <= n; i++ {
+		o := binary.LittleEndian.Uint32(offs[4*i:])
+		if o < prev {
+			return false
+		}
+		prev = o
+	}
+	return true
+}
+
+func (t *table[T]) offset(offs []byte, i int) int {
+	return int(binary.LittleEndian.Uint32(offs[4*i:]))
+}
+
+func (t *table[T]) key(i int) []byte {
+	return t.keys[t.offset(t.keyOffs, i):t.offset(t.keyOffs, i+1)]
+}
+
+func (t *table[T]) values(i int) []T {
+	size := t.codec.Size()
+	from, to := t.offset(t.valOffs, i), t.offset(t.valOffs, i+1)
+	res := make([]T, 0, to-from)
+	for j := from; j < to; j++ {
+		res = append(res, t.codec.Get(t.vals[j*size:]))
+	}
+	return res
+}
+
+func (t *table[T]) find(key string) (int, bool) {
+	i := t.lowerBound(key)
+	return i, i < t.n && string(t.key(i)) == key
+}
+
+func (t *table[T]) get(key string) ([]T, bool) {
+	i, ok := t.find(key)
+	if !ok {
+		return nil, false
+	}
+	return t.values(i), true
+}
+
+func (t *table[T]) has(key string) bool {
+	_, ok := t.find(key)
+	return ok
+}
+
+func (t *table[T]) items() map[string][]T {
+	res := make(map[string][]T, t.n)
+	for i := 0; i < t.n; i++ {
+		res[string(t.key(i))] = t.values(i)
+	}
+	return res
+}
+
+// lowerBound returns index of the first key not less than key.
+func (t *table[T]) lowerBound(key string) int {
+	kb := []byte(key)
+	return sort.Search(t.n, func(i int) bool { return bytes.Compare(t.key(i), kb) >= 0 })
+}
+
+func (t *table[T]) walk(prefix string, fn func(key string, vals []T) bool) {
+	pb := []byte(prefix)
+	for i := t.lowerBound(prefix); i < t.n && bytes.HasPrefix(t.key(i), pb); i++ {
+		if !fn(string(t.key(i)), t.values(i)) {
+			return
+		}
+	}
+}
+
+func (t *table[T]) prefixes(word string) []string {
+	res := make([]string, 0)
+	for i := 1; i <= len(word); i++ {
+		if t.has(word[:i]) {
+			res = append(res, word[:i])
+		}
+	}
+	return res
+}
+
+func (t *table[T]) similar(word string, subs map[rune]rune, fn func(key string, vals []T)) {
+	// A state is the matched key prefix; it is followed while some key
+	// starts with it.
+	follow := func(prefix, s string) (string, bool) {
+		next := prefix + s
+		i := t.lowerBound(next)
+		return next, i < t.n && bytes.HasPrefix(t.key(i), []byte(next))
+	}
+	similarWalk(word, subs, "", follow, func(key string, _ string) {
+		if vals, ok := t.get(key); ok {
+			fn(key, vals)
+		}
+	})
+}
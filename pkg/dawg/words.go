@@ -1,5 +1,7 @@
 package dawg
 
+import "encoding/binary"
+
 // WordForm stores paradigm information for a word form.
 type WordForm struct {
 	ParadigmID uint16
@@ -16,6 +18,58 @@ func NewWordsDawg(data map[string][]WordForm) *WordsDawg {
 	return &WordsDawg{New[WordForm](data)}
 }
 
+// NewWordsDawgFromTable creates a WordsDawg reading data from a binary table
+// produced by EncodeTable with WordFormCodec.
+func NewWordsDawgFromTable(buf []byte) (*WordsDawg, error) {
+	d, err := NewFromTable[WordForm](buf, WordFormCodec{})
+	if err != nil {
+		return nil, err
+	}
+	return &WordsDawg{d}, nil
+}
+
+// WordFormCodec stores WordForm as two uint16 values.
+type WordFormCodec struct{}
+
+func (WordFormCodec) Size() int { return 4 }
+
+func (WordFormCodec) Put(b []byte, v WordForm) {
+	binary.LittleEndian.PutUint16(b[0:], v.ParadigmID)
+	binary.LittleEndian.PutUint16(b[2:], v.FormIndex)
+}
+
+func (WordFormCodec) Get(b []byte) WordForm {
+	return WordForm{ParadigmID: binary.LittleEndian.Uint16(b[0:]), FormIndex: binary.LittleEndian.Uint16(b[2:])}
+}
+
+// LoadWordsDawg loads words.dawg file of a pymorphy2 dictionary.
+func LoadWordsDawg(path string) (*WordsDawg, error) {
+	d, err := LoadRecordDAWG[WordForm](path, wordFormRecordCodec{})
+	if err != nil {
+		return nil, err
+	}
+	return &WordsDawg{d}, nil
+}
+
+// Save writes the DAWG to path in the pymorphy2 words.dawg format.
+func (d *WordsDawg) Save(path string) error {
+	return WriteRecordDAWG(path, d.Data(), wordFormRecordCodec{})
+}
+
+// wordFormRecordCodec matches pymorphy2 WordsDawg ">HH" record format.
+type wordFormRecordCodec struct{}
+
+func (wordFormRecordCodec) Size() int { return 4 }
+
+func (wordFormRecordCodec) Put(b []byte, v WordForm) {
+	binary.BigEndian.PutUint16(b[0:], v.ParadigmID)
+	binary.BigEndian.PutUint16(b[2:], v.FormIndex)
+}
+
+func (wordFormRecordCodec) Get(b []byte) WordForm {
+	return WordForm{ParadigmID: binary.BigEndian.Uint16(b[0:]), FormIndex: binary.BigEndian.Uint16(b[2:])}
+}
+
 // Lookup returns paradigm records for a word.
 func (w *WordsDawg) Lookup(word string) []WordForm {
 	return w.Items(word)
@@ -30,24 +84,16 @@ type WordItem struct {
 // SimilarItems returns all dictionary entries reachable from the given word by
 // applying character substitutions.
 func (w *WordsDawg) SimilarItems(word string, subs map[rune]rune) []WordItem {
-	items := w.DAWG.SimilarItems(word, subs)
-	res := make([]WordItem, 0, len(items))
-	for k, v := range items {
-		cp := make([]WordForm, len(v))
-		copy(cp, v)
-		res = append(res, WordItem{Word: k, Forms: cp})
-	}
+	res := make([]WordItem, 0)
+	w.store.similar(word, subs, func(key string, vals []WordForm) {
+		cp := make([]WordForm, len(vals))
+		copy(cp, vals)
+		res = append(res, WordItem{Word: key, Forms: cp})
+	})
 	return res
 }
 
 // SimilarItemValues returns paradigm records for all similar words.
 func (w *WordsDawg) SimilarItemValues(word string, subs map[rune]rune) [][]WordForm {
-	values := w.DAWG.SimilarItemValues(word, subs)
-	res := make([][]WordForm, len(values))
-	for i, v := range values {
-		cp := make([]WordForm, len(v))
-		copy(cp, v)
-		res[i] = cp
-	}
-	return res
+	return w.DAWG.SimilarItemValues(word, subs)
 }
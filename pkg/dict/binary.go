@@ -0,0 +1,298 @@
+package dict
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"morphy/pkg/dawg"
+	"morphy/pkg/tagset"
+)
+
+// BinaryDictFile is the name of the file holding binary dictionary data.
+// meta.json and grammemes.json are kept next to it in JSON.
+const BinaryDictFile = "dict.bin"
+
+// BinaryFormatVersion is the version of the binary dictionary layout.
+const BinaryFormatVersion = 1
+
+var binaryMagic = [8]byte{'M', 'O', 'R', 'P', 'H', 'Y', 'D', 'B'}
+
+// Binary dictionary layout (little-endian):
+//
+//	[8]byte  magic "MORPHYDB"
+//	uint32   format version
+//	uint32   sections count
+//	{uint64 offset, uint64 length} for every section
+//	section data
+//
+// Sections go in fixed order: gramtab, suffixes, paradigm prefixes,
+// paradigms, words and one section per prediction suffixes table. Words and
+// prediction sections use the dawg table format and are read in place.
+const (
+	sectionGramtab = iota
+	sectionSuffixes
+	sectionParadigmPrefixes
+	sectionParadigms
+	sectionWords
+	sectionPrediction
+)
+
+// WriteBinaryDict writes dictionary data to BinaryDictFile inside path.
+func WriteBinaryDict(path string, ld *LoadedDictionary) error {
+	gramtab := make([]string, len(ld.Gramtab))
+	for i, t := range ld.Gramtab {
+		gramtab[i] = t.String()
+	}
+	sections := [][]byte{
+		encodeStrings(gramtab),
+		encodeStrings(ld.Suffixes),
+		encodeStrings(ld.ParadigmPrefixes),
+		encodeParadigms(ld.Paradigms),
+		dawg.EncodeTable(ld.Words.Data(), dawg.WordFormCodec{}),
+	}
+	for _, pd := range ld.PredictionSuffixes {
+		sections = append(sections, dawg.EncodeTable(pd.Data(), dawg.PredictionCodec{}))
+	}
+
+	var buf bytes.Buffer
+	buf.Write(binaryMagic[:])
+	header := 16 + 16*len(sections)
+	binary.Write(&buf, binary.LittleEndian, uint32(BinaryFormatVersion))
+	binary.Write(&buf, binary.LittleEndian, uint32(len(sections)))
+	offset := uint64(header)
+	for _, s := range sections {
+		binary.Write(&buf, binary.LittleEndian, offset)
+		binary.Write(&buf, binary.LittleEndian, uint64(len(s)))
+		offset += uint64(len(s))
+	}
+	for _, s := range sections {
+		buf.Write(s)
+	}
+	return os.WriteFile(filepath.Join(path, BinaryDictFile), buf.Bytes(), 0o644)
+}
+
+// loadBinaryDict maps BinaryDictFile into memory. Gramtab, suffixes and
+// paradigms are decoded; words and prediction tables are read in place.
+func loadBinaryDict(path string, ld *LoadedDictionary) error {
+	data, release, err := mmapFile(filepath.Join(path, BinaryDictFile))
+	if err != nil {
+		return err
+	}
+	if err := decodeBinaryDict(data, ld); err != nil {
+		release()
+		return err
+	}
+	ld.release = release
+	return nil
+}
+
+func decodeBinaryDict(data []byte, ld *LoadedDictionary) error {
+	if len(data) < 16 || !bytes.Equal(data[:8], binaryMagic[:]) {
+		return fmt.Errorf("%s: not a binary dictionary", BinaryDictFile)
+	}
+	if v := binary.LittleEndian.Uint32(data[8:]); v != BinaryFormatVersion {
+		return fmt.Errorf("%s: unsupported format version %d", BinaryDictFile, v)
+	}
+	count := int(binary.LittleEndian.Uint32(data[12:]))
+	if count < sectionPrediction || len(data) < 16+16*count {
+		return fmt.Errorf("%s: %w", BinaryDictFile, io.ErrUnexpectedEOF)
+	}
+	sections := make([][]byte, count)
+	for i := range sections {
+		off := binary.LittleEndian.Uint64(data[16+16*i:])
+		size := binary.LittleEndian.Uint64(data[24+16*i:])
+		if off+size > uint64(len(data)) {
+			return fmt.Errorf("%s: %w", BinaryDictFile, io.ErrUnexpectedEOF)
+		}
+		sections[i] = data[off : off+size]
+	}
+
+	gramtab, err := decodeStrings(sections[sectionGramtab])
+	if err != nil {
+		return err
+	}
+	ld.Gramtab = make([]tagset.Tag, 0, len(gramtab))
+	for _, t := range gramtab {
+		tg, err := tagset.New(t)
+		if err != nil {
+			return err
+		}
+		ld.Gramtab = append(ld.Gramtab, *tg)
+	}
+	if ld.Suffixes, err = decodeStrings(sections[sectionSuffixes]); err != nil {
+		return err
+	}
+	if ld.ParadigmPrefixes, err = decodeStrings(sections[sectionParadigmPrefixes]); err != nil {
+		return err
+	}
+	if ld.Paradigms, err = decodeParadigms(sections[sectionParadigms]); err != nil {
+		return err
+	}
+	if ld.Words, err = dawg.NewWordsDawgFromTable(sections[sectionWords]); err != nil {
+		return err
+	}
+	ld.PredictionSuffixes = make([]*dawg.PredictionSuffixesDAWG, 0, count-sectionPrediction)
+	for _, s := range sections[sectionPrediction:] {
+		pd, err := dawg.NewPredictionSuffixesDAWGFromTable(s)
+		if err != nil {
+			return err
+		}
+		ld.PredictionSuffixes = append(ld.PredictionSuffixes, pd)
+	}
+	return nil
+}
+
+// ConvertToBinary converts JSON dictionary at srcPath to the binary layout
+// in outPath. outPath may be equal to srcPath; binary data then takes
+// precedence over JSON files when loading, as long as the source is not
+// recompiled (see LoadDict).
+func ConvertToBinary(srcPath, outPath string) error {
+	ld, err := loadDict(srcPath, false)
+	if err != nil {
+		return err
+	}
+	defer ld.Close()
+	hash, _ := ld.Meta["content_hash"].(string)
+	if hash == "" && filepath.Clean(srcPath) == filepath.Clean(outPath) {
+		return fmt.Errorf("%s: dictionary has no content_hash, convert it into another directory", srcPath)
+	}
+	if err := os.MkdirAll(outPath, 0o755); err != nil {
+		return err
+	}
+	if filepath.Clean(srcPath) != filepath.Clean(outPath) {
+		for _, name := range []string{"meta.json", "grammemes.json", "p_t_given_w.intdawg", LemmaIDsFile} {
+			b, err := os.ReadFile(filepath.Join(srcPath, name))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(filepath.Join(outPath, name), b, 0o644); err != nil {
+				return err
+			}
+		}
+	}
+	if err := WriteBinaryDict(outPath, ld); err != nil {
+		return err
+	}
+	if hash == "" {
+		return nil
+	}
+	return UpdateMeta(outPath, map[string]any{"binary_content_hash": hash})
+}
+
+// checkBinaryDict returns an error if BinaryDictFile at path was converted
+// from other data than the JSON or pymorphy2 files next to it, e.g. before
+// the dictionary was recompiled.
+func checkBinaryDict(path string, meta map[string]any) error {
+	hasSource := false
+	for _, name := range []string{"words.json", Pymorphy2WordsFile} {
+		if _, err := os.Stat(filepath.Join(path, name)); err == nil {
+			hasSource = true
+		}
+	}
+	if !hasSource {
+		return nil
+	}
+	hash, _ := meta["content_hash"].(string)
+	if binHash, _ := meta["binary_content_hash"].(string); hash == "" || binHash != hash {
+		return fmt.Errorf("%s: %s is stale, convert the dictionary again or remove it", path, BinaryDictFile)
+	}
+	return nil
+}
+
+// encodeStrings stores strings as uint32 count, uint32 offsets[count+1] and
+// string bytes.
+func encodeStrings(items []string) []byte {
+	size := 4 + 4*(len(items)+1)
+	for _, s := range items {
+		size += len(s)
+	}
+	buf := make([]byte, size)
+	binary.LittleEndian.PutUint32(buf, uint32(len(items)))
+	offs := buf[4:]
+	data := offs[4*(len(items)+1):]
+	pos := 0
+	for i, s := range items {
+		binary.LittleEndian.PutUint32(offs[4*i:], uint32(pos))
+		pos += copy(data[pos:], s)
+	}
+	binary.LittleEndian.PutUint32(offs[4*len(items):], uint32(pos))
+	return buf
+}
+
+func decodeStrings(buf []byte) ([]string, error) {
+	if len(buf) < 4 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	n := int(binary.LittleEndian.Uint32(buf))
+	if len(buf) < 4+4*(n+1) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	offs := buf[4:]
+	data := offs[4*(n+1):]
+	res := make([]string, n)
+	for i := range res {
+		from := binary.LittleEndian.Uint32(offs[4*i:])
+		to := binary.LittleEndian.Uint32(offs[4*(i+1):])
+		if from > to || int(to) > len(data) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		res[i] = string(data[from:to])
+	}
+	return res, nil
+}
+
+// encodeParadigms stores paradigms as uint32 count, uint32 offsets[count+1]
+// (in uint16 units) and uint16 paradigm data.
+func encodeParadigms(paradigms [][]uint16) []byte {
+	total := 0
+	for _, p := range paradigms {
+		total += len(p)
+	}
+	buf := make([]byte, 4+4*(len(paradigms)+1)+2*total)
+	binary.LittleEndian.PutUint32(buf, uint32(len(paradigms)))
+	offs := buf[4:]
+	data := offs[4*(len(paradigms)+1):]
+	pos := 0
+	for i, p := range paradigms {
+		binary.LittleEndian.PutUint32(offs[4*i:], uint32(pos))
+		for _, v := range p {
+			binary.LittleEndian.PutUint16(data[2*pos:], v)
+			pos++
+		}
+	}
+	binary.LittleEndian.PutUint32(offs[4*len(paradigms):], uint32(pos))
+	return buf
+}
+
+func decodeParadigms(buf []byte) ([][]uint16, error) {
+	if len(buf) < 4 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	n := int(binary.LittleEndian.Uint32(buf))
+	if len(buf) < 4+4*(n+1) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	offs := buf[4:]
+	data := offs[4*(n+1):]
+	res := make([][]uint16, n)
+	for i := range res {
+		from := int(binary.LittleEndian.Uint32(offs[4*i:]))
+		to := int(binary.LittleEndian.Uint32(offs[4*(i+1):]))
+		if from > to || 2*to > len(data) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		para := make([]uint16, to-from)
+		for j := range para {
+			para[j] = binary.LittleEndian.Uint16(data[2*(from+j):])
+		}
+		res[i] = para
+	}
+	return res, nil
+}
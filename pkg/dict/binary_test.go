@@ -0,0 +1,123 @@
+package dict
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConvertToBinary(t *testing.T) {
+	jsonPath, binPath := saveTestDict(t, compileTestDict(t, nil, nil)), t.TempDir()
+	if err := ConvertToBinary(jsonPath, binPath); err != nil {
+		t.Fatal(err)
+	}
+	want, err := LoadDict(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadDict(binPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Close()
+	if got.release == nil {
+		t.Fatal("binary dictionary was not detected")
+	}
+	if !reflect.DeepEqual(got.Paradigms, want.Paradigms) || !reflect.DeepEqual(got.Suffixes, want.Suffixes) {
+		t.Errorf("paradigms or suffixes differ")
+	}
+	if !reflect.DeepEqual(got.Words.Data(), want.Words.Data()) {
+		t.Errorf("words differ: %v != %v", got.Words.Data(), want.Words.Data())
+	}
+	if forms := got.Words.Lookup("ежа"); len(forms) != 1 || forms[0].FormIndex != 1 {
+		t.Errorf("unexpected lookup result: %v", forms)
+	}
+	if len(got.PredictionSuffixes) != len(want.PredictionSuffixes) {
+		t.Fatalf("prediction tables count differs")
+	}
+	for i := range got.PredictionSuffixes {
+		if !reflect.DeepEqual(got.PredictionSuffixes[i].Data(), want.PredictionSuffixes[i].Data()) {
+			t.Errorf("prediction table %d differs", i)
+		}
+	}
+}
+
+func TestConvertToBinaryInPlace(t *testing.T) {
+	compiled := compileTestDict(t, nil, nil)
+	path := saveTestDict(t, compiled)
+	if err := ConvertToBinary(path, path); err != nil {
+		t.Fatal(err)
+	}
+	ld, err := LoadDict(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ld.release == nil {
+		t.Fatal("binary dictionary was not detected")
+	}
+	ld.Close()
+
+	// recompiled JSON data must not be shadowed by the old binary data
+	compiled.ParsedDict.Lexemes["3"] = []WordForm{{Word: "уж", Tag: "NOUN,anim,masc sing,nomn"}}
+	compiled = compileTestDict(t, compiled.ParsedDict, nil)
+	stale, err := os.ReadFile(filepath.Join(path, BinaryDictFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveCompiledDict(compiled, path, "test", "ru"); err != nil {
+		t.Fatal(err)
+	}
+	if ld, err = LoadDict(path); err != nil {
+		t.Fatal(err)
+	}
+	if ld.release != nil || len(ld.Words.Lookup("уж")) != 1 {
+		t.Fatal("stale binary dictionary was loaded")
+	}
+	if err := os.WriteFile(filepath.Join(path, BinaryDictFile), stale, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDict(path); err == nil {
+		t.Fatal("stale binary dictionary was loaded")
+	}
+	if err := ConvertToBinary(path, path); err != nil {
+		t.Fatal(err)
+	}
+	ld, err = LoadDict(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ld.Close()
+	if forms := ld.Words.Lookup("уж"); len(forms) != 1 {
+		t.Errorf("Lookup(уж) = %v after conversion", forms)
+	}
+}
+
+func TestLemmaIDs(t *testing.T) {
+	parsed := parseTestDict(t)
+	compiled := compileTestDict(t, parsed, map[string]any{"lemma_ids": true})
+	jsonPath, nativePath, binPath := saveTestDict(t, compiled), t.TempDir(), t.TempDir()
+	if err := SavePymorphy2Dict(compiled, nativePath, "test", "ru"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ConvertToBinary(jsonPath, binPath); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{jsonPath, nativePath, binPath} {
+		d := openTestDict(t, path)
+		forms := d.Words().Lookup("ежи")
+		if len(forms) != 1 {
+			t.Fatalf("%s: forms of ежи = %v", path, forms)
+		}
+		normal := d.BuildNormalForm(int(forms[0].ParadigmID), int(forms[0].FormIndex), "ежи")
+		// lemma 2 is merged into lemma 1 by a link
+		if got := d.LemmaID(int(forms[0].ParadigmID), normal); got != 1 {
+			t.Errorf("%s: LemmaID = %d, want 1", path, got)
+		}
+	}
+
+	// lexeme IDs are not lemma IDs unless the caller says so
+	if compiled = compileTestDict(t, parsed, nil); compiled.LemmaIDs != nil {
+		t.Errorf("LemmaIDs stored without the lemma_ids option")
+	}
+}
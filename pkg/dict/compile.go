@@ -1,9 +1,15 @@
 package dict
 
 import (
+	"cmp"
+	"encoding/binary"
 	"fmt"
+	"math"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"morphy/pkg/dawg"
 	"morphy/pkg/utils"
@@ -19,84 +25,246 @@ type CompiledDictionary struct {
 	ParsedDict              *ParsedDictionary
 	CompileOptions          map[string]any
 	ParadigmPrefixes        []string
+	// ParadigmPopularity holds number of lexemes sharing each paradigm.
+	ParadigmPopularity []int
+	// LexemesCount is the number of lexemes after link merging.
+	LexemesCount int
+	// LemmaIDs maps normal forms and paradigms to source lemma IDs. Lexemes
+	// merged by links keep the ID of the lemma at the link start. It is nil
+	// unless the "lemma_ids" option is set.
+	LemmaIDs *dawg.LemmaIDsDAWG
+	// CompiledAt is written to meta as compiled_at unless it is zero.
+	CompiledAt time.Time
 }
 
 // ConvertToPymorphy2 converts OpenCorpora XML dict to compiled format and saves it.
+// Paradigm prefixes default to the ones of languageCode (see
+// LanguageCompileOptions) and lemma IDs are stored unless the "lemma_ids"
+// option is false.
 func ConvertToPymorphy2(xmlPath, outPath, sourceName, languageCode string, overwrite bool, options map[string]any) error {
+	compiled, err := compileXML(xmlPath, outPath, overwrite, LanguageCompileOptions(languageCode, options))
+	if err != nil {
+		return err
+	}
+	return SaveCompiledDict(compiled, outPath, sourceName, languageCode)
+}
+
+// ConvertToPymorphy2Native converts OpenCorpora XML dict and saves it in the
+// native pymorphy2 layout (see SavePymorphy2Dict). Options default as in
+// ConvertToPymorphy2.
+func ConvertToPymorphy2Native(xmlPath, outPath, sourceName, languageCode string, overwrite bool, options map[string]any) error {
+	compiled, err := compileXML(xmlPath, outPath, overwrite, LanguageCompileOptions(languageCode, options))
+	if err != nil {
+		return err
+	}
+	return SavePymorphy2Dict(compiled, outPath, sourceName, languageCode)
+}
+
+func compileXML(xmlPath, outPath string, overwrite bool, options map[string]any) (*CompiledDictionary, error) {
 	if !overwrite {
 		if _, err := os.Stat(outPath); err == nil {
-			return fmt.Errorf("output path exists")
+			return nil, fmt.Errorf("output path exists")
 		}
 	}
 	parsed, err := ParseOpencorporaXML(xmlPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	SimplifyTags(parsed, true)
 	DropUnsupportedParses(parsed)
-	compiled, err := CompileParsedDict(parsed, options)
-	if err != nil {
-		return err
+	// lexemes of OpenCorpora XML are keyed by lemma IDs
+	if _, ok := options["lemma_ids"]; !ok {
+		options["lemma_ids"] = true
 	}
-	return SaveCompiledDict(compiled, outPath, sourceName, languageCode)
+	return CompileParsedDict(parsed, options)
 }
 
 // CompileParsedDict builds compact representation from parsed dictionary.
+// Paradigm prefixes allowed in stems are taken from the "paradigm_prefixes"
+// option, usually set from the language config (see LanguageCompileOptions).
+// With the "lemma_ids" option set to true numeric lexeme IDs are stored as
+// source lemma IDs (see CompiledDictionary.LemmaIDs); set it only when
+// lexemes are keyed by the IDs of the source, as in OpenCorpora XML.
+//
+// Lexemes are compiled on a pool of GOMAXPROCS workers and merged in the
+// order of their IDs, so the same input always gives the same tables. With
+// the "reproducible" option set to true the compile time is not recorded
+// and saved dictionaries are byte-identical.
 func CompileParsedDict(parsed *ParsedDictionary, compileOptions map[string]any) (*CompiledDictionary, error) {
+	options := compileOptionsWithDefaults(compileOptions)
+
 	suffixes := []string{}
 	suffixIDs := map[string]uint16{}
 	gramtab := []string{}
 	tagIDs := map[string]uint16{}
-	prefixes := []string{""}
-	prefixIDs := map[string]uint16{"": 0}
+	prefixes := stringsOption(options, "paradigm_prefixes", DefaultParadigmPrefixes)
+	if len(prefixes) > math.MaxUint16+1 {
+		return nil, fmt.Errorf("too many paradigm prefixes")
+	}
+	prefixIDs := make(map[string]uint16, len(prefixes))
+	for i, p := range prefixes {
+		prefixIDs[p] = uint16(i)
+	}
+	if _, ok := prefixIDs[""]; !ok {
+		return nil, fmt.Errorf("paradigm prefixes must include the empty prefix")
+	}
 
 	paradigms := [][]uint16{}
-	words := map[string][]dawg.WordForm{}
+	paradigmIDs := map[string]uint16{}
+	words := []wordEntry{}
+	paradigmPopularity := map[uint16]int{}
+	var lemmaIDs map[string][]dawg.LemmaRef
+	if boolOption(options, "lemma_ids") {
+		lemmaIDs = map[string][]dawg.LemmaRef{}
+	}
 
-	for _, lexeme := range parsed.Lexemes {
-		stem, para := toParadigm(lexeme)
+	lexemes := JoinLexemes(parsed.Lexemes, parsed.Links, stringsOption(options, "skip_link_types", SkippedLinkTypes))
+	// Stems and paradigms are extracted in parallel; IDs are then assigned
+	// sequentially in lexeme ID order, so the result does not depend on
+	// scheduling.
+	ids := sortedLexemeIDs(lexemes)
+	stems := make([]string, len(ids))
+	paras := make([][]formInfo, len(ids))
+	utils.ParallelFor(len(ids), 0, func(i int) {
+		stems[i], paras[i] = toParadigm(lexemes[ids[i]], prefixIDs)
+	})
+	for n, id := range ids {
+		stem, para := stems[n], paras[n]
+		if len(para) > math.MaxUint16+1 {
+			return nil, fmt.Errorf("lexeme %s has too many forms", ids[n])
+		}
 		paraArr := make([]uint16, len(para)*3)
 		for i, f := range para {
 			sid, ok := suffixIDs[f.Suffix]
 			if !ok {
+				if len(suffixes) > math.MaxUint16 {
+					return nil, fmt.Errorf("too many suffixes")
+				}
 				sid = uint16(len(suffixes))
 				suffixes = append(suffixes, f.Suffix)
 				suffixIDs[f.Suffix] = sid
 			}
 			tid, ok := tagIDs[f.Tag]
 			if !ok {
+				if len(gramtab) > math.MaxUint16 {
+					return nil, fmt.Errorf("too many tags")
+				}
 				tid = uint16(len(gramtab))
 				gramtab = append(gramtab, f.Tag)
 				tagIDs[f.Tag] = tid
 			}
-			pid, ok := prefixIDs[f.Prefix]
-			if !ok {
-				pid = uint16(len(prefixes))
-				prefixes = append(prefixes, f.Prefix)
-				prefixIDs[f.Prefix] = pid
-			}
+			pid := prefixIDs[f.Prefix]
 			paraArr[i] = sid
 			paraArr[len(para)+i] = tid
 			paraArr[2*len(para)+i] = pid
+		}
+		key := paradigmKey(paraArr)
+		paraID, ok := paradigmIDs[key]
+		if !ok {
+			if len(paradigms) > math.MaxUint16 {
+				return nil, fmt.Errorf("too many paradigms")
+			}
+			paraID = uint16(len(paradigms))
+			paradigmIDs[key] = paraID
+			paradigms = append(paradigms, paraArr)
+		}
+		paradigmPopularity[paraID]++
+		// only numeric (OpenCorpora) lemma IDs are kept
+		if lemmaID, err := strconv.ParseUint(id, 10, 32); err == nil && lemmaIDs != nil {
+			normal := para[0].Prefix + stem + para[0].Suffix
+			lemmaIDs[normal] = append(lemmaIDs[normal], dawg.LemmaRef{ParadigmID: paraID, LemmaID: uint32(lemmaID)})
+		}
+		for i, f := range para {
 			word := f.Prefix + stem + f.Suffix
-			words[word] = append(words[word], dawg.WordForm{ParadigmID: uint16(len(paradigms)), FormIndex: uint16(i)})
+			words = append(words, wordEntry{Word: word, ParadigmID: paraID, FormIndex: uint16(i)})
 		}
-		paradigms = append(paradigms, paraArr)
+	}
+	popularity := make([]int, len(paradigms))
+	for id, cnt := range paradigmPopularity {
+		popularity[id] = cnt
 	}
 
-	wd := dawg.NewWordsDawg(words)
+	predictionData := suffixesPredictionData(
+		words, paradigmPopularity, gramtab, paradigms, suffixes, prefixes,
+		intOption(options, "min_ending_freq", DefaultMinEndingFreq),
+		intOption(options, "min_paradigm_popularity", DefaultMinParadigmPopularity),
+		intOption(options, "max_suffix_length", DefaultMaxSuffixLength),
+	)
+	predictionDawgs := make([]*dawg.PredictionSuffixesDAWG, len(predictionData))
+	for i, data := range predictionData {
+		predictionDawgs[i] = dawg.NewPredictionSuffixesDAWG(data)
+	}
+
+	wordsData := map[string][]dawg.WordForm{}
+	for _, w := range words {
+		wordsData[w.Word] = append(wordsData[w.Word], dawg.WordForm{ParadigmID: w.ParadigmID, FormIndex: w.FormIndex})
+	}
+	wd := dawg.NewWordsDawg(wordsData)
+	var lemmaIDsDawg *dawg.LemmaIDsDAWG
+	if lemmaIDs != nil {
+		lemmaIDsDawg = dawg.NewLemmaIDsDAWG(lemmaIDs)
+	}
+	var compiledAt time.Time
+	if !boolOption(options, "reproducible") {
+		compiledAt = time.Now().UTC().Truncate(time.Second)
+	}
 	return &CompiledDictionary{
 		Gramtab:                 gramtab,
 		Suffixes:                suffixes,
 		Paradigms:               paradigms,
 		WordsDawg:               wd,
-		PredictionSuffixesDawgs: []*dawg.PredictionSuffixesDAWG{},
+		PredictionSuffixesDawgs: predictionDawgs,
 		ParsedDict:              parsed,
-		CompileOptions:          compileOptions,
+		CompileOptions:          options,
 		ParadigmPrefixes:        prefixes,
+		ParadigmPopularity:      popularity,
+		LexemesCount:            len(lexemes),
+		LemmaIDs:                lemmaIDsDawg,
+		CompiledAt:              compiledAt,
 	}, nil
 }
 
+// sortedLexemeIDs returns lexeme IDs ordered numerically (OpenCorpora lemma
+// IDs), followed by non-numeric IDs in byte order.
+func sortedLexemeIDs(lexemes map[string][]WordForm) []string {
+	type lexemeID struct {
+		id  string
+		num uint64
+		ok  bool
+	}
+	ids := make([]lexemeID, 0, len(lexemes))
+	for id := range lexemes {
+		num, err := strconv.ParseUint(id, 10, 64)
+		ids = append(ids, lexemeID{id: id, num: num, ok: err == nil})
+	}
+	slices.SortFunc(ids, func(a, b lexemeID) int {
+		switch {
+		case a.ok && b.ok && a.num != b.num:
+			return cmp.Compare(a.num, b.num)
+		case a.ok != b.ok:
+			if a.ok {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.id, b.id)
+	})
+	res := make([]string, len(ids))
+	for i, id := range ids {
+		res[i] = id.id
+	}
+	return res
+}
+
+// paradigmKey returns a string identifying linearized paradigm contents.
+func paradigmKey(paradigm []uint16) string {
+	b := make([]byte, 2*len(paradigm))
+	for i, v := range paradigm {
+		binary.LittleEndian.PutUint16(b[2*i:], v)
+	}
+	return string(b)
+}
+
 // formInfo represents part of paradigm.
 type formInfo struct {
 	Suffix string
@@ -104,26 +272,32 @@ type formInfo struct {
 	Prefix string
 }
 
-func toParadigm(lexeme []WordForm) (string, []formInfo) {
+// toParadigm extracts stem and paradigm from lexeme. Forms may only have
+// prefixes listed in allowedPrefixes; otherwise an empty stem is used.
+func toParadigm(lexeme []WordForm, allowedPrefixes map[string]uint16) (string, []formInfo) {
 	forms := make([]string, len(lexeme))
 	tags := make([]string, len(lexeme))
 	for i, wf := range lexeme {
 		forms[i] = wf.Word
 		tags[i] = wf.Tag
 	}
+	prefixes := make([]string, len(forms))
 	var stem string
 	if len(forms) == 1 {
 		stem = forms[0]
 	} else {
 		stem = utils.LongestCommonSubstring(forms)
-	}
-	prefixes := make([]string, len(forms))
-	for i, form := range forms {
-		idx := strings.Index(form, stem)
-		if idx < 0 {
-			idx = 0
+		for i, form := range forms {
+			prefixes[i] = form[:strings.Index(form, stem)]
+		}
+		for _, pref := range prefixes {
+			if _, ok := allowedPrefixes[pref]; !ok {
+				// With proper paradigm prefixes an empty stem is fine.
+				stem = ""
+				prefixes = make([]string, len(forms))
+				break
+			}
 		}
-		prefixes[i] = form[:idx]
 	}
 	res := make([]formInfo, len(forms))
 	for i, form := range forms {
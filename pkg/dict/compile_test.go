@@ -0,0 +1,178 @@
+package dict
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestReproducibleBuild(t *testing.T) {
+	parsed := parseTestDict(t)
+	// distinct paradigms, so the order of lexemes shows in every table
+	for i := 0; i < 20; i++ {
+		suffix := strings.Repeat("а", i+1)
+		parsed.Lexemes[fmt.Sprint(100+i)] = []WordForm{
+			{Word: "кот" + suffix, Tag: "NOUN,anim,masc sing,nomn"},
+			{Word: "кот" + suffix + "у", Tag: "NOUN,anim,masc sing,datv"},
+		}
+	}
+	parsed.Lexemes["user"] = []WordForm{{Word: "кото", Tag: "NOUN,inan,neut,Fixd sing,nomn"}}
+	options := map[string]any{"min_paradigm_popularity": 1, "reproducible": true}
+	build := func(save func(*CompiledDictionary, string, string, string) error) map[string]string {
+		compiled, err := CompileParsedDict(parsed, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !compiled.CompiledAt.IsZero() {
+			t.Fatalf("CompiledAt = %v, want zero", compiled.CompiledAt)
+		}
+		return savedFiles(t, compiled, save)
+	}
+	for _, save := range []func(*CompiledDictionary, string, string, string) error{SaveCompiledDict, SavePymorphy2Dict} {
+		want := build(save)
+		if meta := want["meta.json"]; strings.Contains(meta, "compiled_at") || !strings.Contains(meta, `"content_hash"`) {
+			t.Fatalf("unexpected meta.json:\n%s", meta)
+		}
+		for i := 0; i < 10; i++ {
+			got := build(save)
+			if len(got) != len(want) {
+				t.Fatalf("build %d: %d files, want %d", i, len(got), len(want))
+			}
+			for name, b := range want {
+				if got[name] != b {
+					t.Fatalf("build %d: %s differs", i, name)
+				}
+			}
+		}
+	}
+}
+
+// savedFiles saves compiled with save and returns the contents of the
+// written files by name.
+func savedFiles(t *testing.T, compiled *CompiledDictionary, save func(*CompiledDictionary, string, string, string) error) map[string]string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := save(compiled, dir, "test", "ru"); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[e.Name()] = string(b)
+	}
+	return files
+}
+
+// TestCompileGOMAXPROCS checks that SimplifyTags and CompileParsedDict give
+// the same dictionary with one and with many workers.
+func TestCompileGOMAXPROCS(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+	build := func(procs int) map[string]string {
+		runtime.GOMAXPROCS(procs)
+		parsed := parseTestDict(t)
+		for i := 0; i < 500; i++ {
+			suffix := strings.Repeat("а", i%20+1)
+			// the same tag spelled in two grammeme orders
+			tag := "NOUN,anim,masc sing,nomn"
+			if i%3 == 0 {
+				tag = "NOUN,masc,anim sing,nomn"
+			}
+			parsed.Lexemes[fmt.Sprint(100+i)] = []WordForm{
+				{Word: fmt.Sprint("кот", i%7) + suffix, Tag: tag},
+				{Word: fmt.Sprint("кот", i%7) + suffix + "у", Tag: "NOUN,anim,masc sing,datv"},
+			}
+		}
+		SimplifyTags(parsed, true)
+		compiled, err := CompileParsedDict(parsed, map[string]any{"min_paradigm_popularity": 1, "reproducible": true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return savedFiles(t, compiled, SaveCompiledDict)
+	}
+	want := build(1)
+	got := build(8)
+	if len(got) != len(want) {
+		t.Fatalf("%d files with GOMAXPROCS=8, want %d", len(got), len(want))
+	}
+	for name, b := range want {
+		if got[name] != b {
+			t.Errorf("%s differs between GOMAXPROCS=1 and GOMAXPROCS=8", name)
+		}
+	}
+}
+
+func TestParadigmPopularity(t *testing.T) {
+	parsed := &ParsedDictionary{
+		Lexemes: map[string][]WordForm{
+			"1": {{Word: "мама", Tag: "NOUN,anim,femn sing,nomn"}, {Word: "мамы", Tag: "NOUN,anim,femn sing,gent"}},
+			"2": {{Word: "рама", Tag: "NOUN,anim,femn sing,nomn"}, {Word: "рамы", Tag: "NOUN,anim,femn sing,gent"}},
+			"3": {{Word: "кот", Tag: "NOUN,anim,masc sing,nomn"}, {Word: "кота", Tag: "NOUN,anim,masc sing,gent"}},
+			"4": {{Word: "читать", Tag: "INFN,impf,tran"}},
+			"5": {{Word: "читающий", Tag: "PRTF,impf,tran,pres,actv masc,sing,nomn"}},
+			// shares the paradigm of "читать" merged with "читающий"
+			"6": {{Word: "знать", Tag: "INFN,impf,tran"}, {Word: "знающий", Tag: "PRTF,impf,tran,pres,actv masc,sing,nomn"}},
+		},
+		Links: []Link{{From: "4", To: "5", Type: "4"}},
+	}
+	compiled, err := CompileParsedDict(parsed, map[string]any{"reproducible": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compiled.LexemesCount != 5 {
+		t.Errorf("LexemesCount = %d, want 5", compiled.LexemesCount)
+	}
+	paradigm := func(word string) uint16 {
+		t.Helper()
+		forms := compiled.WordsDawg.Items(word)
+		if len(forms) != 1 {
+			t.Fatalf("Items(%q) = %v", word, forms)
+		}
+		return forms[0].ParadigmID
+	}
+	if paradigm("мама") != paradigm("рамы") {
+		t.Errorf("identical paradigms of мама and рама have different IDs")
+	}
+	if paradigm("мама") == paradigm("кот") {
+		t.Errorf("different paradigms of мама and кот have the same ID")
+	}
+	want := map[string]int{"мама": 2, "кот": 1, "знать": 2}
+	if len(compiled.Paradigms) != len(want) || len(compiled.ParadigmPopularity) != len(want) {
+		t.Fatalf("%d paradigms, popularity %v", len(compiled.Paradigms), compiled.ParadigmPopularity)
+	}
+	for word, n := range want {
+		if got := compiled.ParadigmPopularity[paradigm(word)]; got != n {
+			t.Errorf("popularity of the paradigm of %q = %d, want %d", word, got, n)
+		}
+	}
+}
+
+func TestCompileIDOverflow(t *testing.T) {
+	forms := make([]WordForm, math.MaxUint16+2)
+	for i := range forms {
+		forms[i] = WordForm{Word: fmt.Sprintf("мама%d", i), Tag: "NOUN,anim,femn sing,nomn"}
+	}
+	parsed := &ParsedDictionary{Lexemes: map[string][]WordForm{"1": forms}}
+	if _, err := CompileParsedDict(parsed, nil); err == nil || !strings.Contains(err.Error(), "too many forms") {
+		t.Errorf("expected too many forms error, got %v", err)
+	}
+
+	parsed = &ParsedDictionary{Lexemes: map[string][]WordForm{}}
+	for i := 0; i <= math.MaxUint16+1; i++ {
+		parsed.Lexemes[strconv.Itoa(i)] = []WordForm{{Word: "мама", Tag: fmt.Sprintf("NOUN,g%d", i)}}
+	}
+	if _, err := CompileParsedDict(parsed, nil); err == nil || !strings.Contains(err.Error(), "too many tags") {
+		t.Errorf("expected too many tags error, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package dict
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"strings"
+)
+
+// CorpusToken is a token of an annotated OpenCorpora corpus
+// (annot.opcorpora.xml) with parses left after disambiguation. A token with
+// a single parse is fully disambiguated.
+type CorpusToken struct {
+	Text   string
+	Parses []CorpusParse
+}
+
+// CorpusParse is a lemma and tag variant of a corpus token.
+type CorpusParse struct {
+	Lemma string
+	Tag   string
+}
+
+type xmlToken struct {
+	Text string `xml:"text,attr"`
+	Vs   []struct {
+		L struct {
+			T string `xml:"t,attr"`
+			xmlGrams
+		} `xml:"l"`
+	} `xml:"tfr>v"`
+}
+
+// StreamOpencorporaCorpus reads annotated OpenCorpora corpus from r in a
+// single pass and passes every token to fn.
+func StreamOpencorporaCorpus(r io.Reader, fn func(CorpusToken) error) error {
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok || se.Name.Local != "token" {
+			continue
+		}
+		var t xmlToken
+		if err := dec.DecodeElement(&t, &se); err != nil {
+			return err
+		}
+		ct := CorpusToken{Text: t.Text, Parses: make([]CorpusParse, 0, len(t.Vs))}
+		for _, v := range t.Vs {
+			ct.Parses = append(ct.Parses, CorpusParse{Lemma: v.L.T, Tag: v.L.join()})
+		}
+		if err := fn(ct); err != nil {
+			return err
+		}
+	}
+}
+
+// DisambiguatedWords reads corpus file at path and returns (word, tag) pairs
+// of tokens having exactly one parse.
+func DisambiguatedWords(path string) ([]WordForm, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var res []WordForm
+	err = StreamOpencorporaCorpus(f, func(t CorpusToken) error {
+		if len(t.Parses) == 1 {
+			res = append(res, WordForm{Word: t.Text, Tag: t.Parses[0].Tag})
+		}
+		return nil
+	})
+	return res, err
+}
+
+// TagGrammemes returns sorted grammemes of an OpenCorpora tag string with
+// redundant grammemes (loc1, gen1, acc1) replaced.
+func TagGrammemes(tag string) []string {
+	return tag2grammemes(strings.TrimSpace(tag))
+}
@@ -1,6 +1,10 @@
 package dict
 
 import (
+	"fmt"
+	"iter"
+	"math"
+	"slices"
 	"strings"
 
 	"morphy/pkg/dawg"
@@ -17,14 +21,45 @@ type Dictionary struct {
 	predictionDAWGs  []*dawg.PredictionSuffixesDAWG
 	meta             map[string]any
 	path             string
+	release          func() error
+	lemmaIDs         *dawg.LemmaIDsDAWG
+	// user lexicon overlay, see AddLexicon
+	userWords *dawg.WordsDawg
+	userData  map[string][]dawg.WordForm
 }
 
-// NewDictionary loads dictionary from path.
+// DictionaryOptions configures NewDictionaryWithOptions.
+type DictionaryOptions struct {
+	// SkipValidation disables the integrity check done on load.
+	SkipValidation bool
+	// FullValidation makes the check on load walk all words, predictions
+	// and lemma IDs (see Validate). By default only meta and paradigms are
+	// checked, so memory-mapped data is not read as a whole.
+	FullValidation bool
+}
+
+// NewDictionary loads dictionary from path and checks its meta and
+// paradigms. Both JSON and binary layouts are supported.
 func NewDictionary(path string) (*Dictionary, error) {
+	return NewDictionaryWithOptions(path, DictionaryOptions{})
+}
+
+// NewDictionaryWithOptions loads dictionary from path.
+func NewDictionaryWithOptions(path string, opts DictionaryOptions) (*Dictionary, error) {
 	ld, err := LoadDict(path)
 	if err != nil {
 		return nil, err
 	}
+	if !opts.SkipValidation {
+		validate := validateHeader
+		if opts.FullValidation {
+			validate = Validate
+		}
+		if err := validate(ld); err != nil {
+			ld.Close()
+			return nil, fmt.Errorf("%s: invalid dictionary: %w", path, err)
+		}
+	}
 	return &Dictionary{
 		paradigms:        ld.Paradigms,
 		gramtab:          ld.Gramtab,
@@ -32,11 +67,24 @@ func NewDictionary(path string) (*Dictionary, error) {
 		suffixes:         ld.Suffixes,
 		words:            ld.Words,
 		predictionDAWGs:  ld.PredictionSuffixes,
+		lemmaIDs:         ld.LemmaIDs,
 		meta:             ld.Meta,
 		path:             path,
+		release:          ld.Close,
 	}, nil
 }
 
+// Close releases memory-mapped dictionary data. The dictionary must not be
+// used after Close.
+func (d *Dictionary) Close() error {
+	if d.release == nil {
+		return nil
+	}
+	err := d.release()
+	d.release = nil
+	return err
+}
+
 // BuildTagInfo returns tag for given paradigm and form index.
 func (d *Dictionary) BuildTagInfo(paraID int, idx int) tagset.Tag {
 	paradigm := d.paradigms[paraID]
@@ -90,6 +138,27 @@ func (d *Dictionary) BuildStem(paradigm []uint16, idx int, fixedWord string) str
 	return fixedWord[len(pref):]
 }
 
+// LemmaIDs returns source lemma IDs of the lexeme with the paradigm and
+// normal form, in ascending order. Homonyms sharing a paradigm have several
+// IDs; words of the user lexicon and dictionaries compiled without IDs have
+// none.
+func (d *Dictionary) LemmaIDs(paraID int, normalForm string) []uint32 {
+	if d.lemmaIDs == nil || paraID < 0 || paraID > math.MaxUint16 {
+		return nil
+	}
+	ids := d.lemmaIDs.Lookup(normalForm, uint16(paraID))
+	slices.Sort(ids)
+	return ids
+}
+
+// LemmaID returns the smallest of LemmaIDs or 0 if there are none.
+func (d *Dictionary) LemmaID(paraID int, normalForm string) uint32 {
+	if ids := d.LemmaIDs(paraID, normalForm); len(ids) > 0 {
+		return ids[0]
+	}
+	return 0
+}
+
 // Words returns underlying words DAWG.
 func (d *Dictionary) Words() *dawg.WordsDawg { return d.words }
 
@@ -101,6 +170,12 @@ func (d *Dictionary) PredictionSuffixes() []*dawg.PredictionSuffixesDAWG {
 // ParadigmPrefixes returns paradigm prefixes list.
 func (d *Dictionary) ParadigmPrefixes() []string { return d.paradigmPrefixes }
 
+// LanguageCode returns the language_code recorded in meta, e.g. "ru".
+func (d *Dictionary) LanguageCode() string {
+	code, _ := d.meta["language_code"].(string)
+	return code
+}
+
 // MaxSuffixLength retrieves maximum suffix length used for predictions from meta.
 func (d *Dictionary) MaxSuffixLength() int {
 	if opts, ok := d.meta["compile_options"].(map[string]any); ok {
@@ -116,20 +191,40 @@ func (d *Dictionary) MaxSuffixLength() int {
 	return 0
 }
 
-// WordIsKnown reports whether word is present in dictionary, accounting for
-// character substitutes.
+// SimilarItems returns dictionary entries reachable from word by applying
+// character substitutions. Entries of the user lexicon come first.
+func (d *Dictionary) SimilarItems(word string, subs map[rune]rune) []dawg.WordItem {
+	items := d.words.SimilarItems(word, subs)
+	if d.userWords == nil {
+		return items
+	}
+	return append(d.userWords.SimilarItems(word, subs), items...)
+}
+
+// SimilarItemValues returns forms of entries reachable from word by applying
+// character substitutions. Entries of the user lexicon come first.
+func (d *Dictionary) SimilarItemValues(word string, subs map[rune]rune) [][]dawg.WordForm {
+	values := d.words.SimilarItemValues(word, subs)
+	if d.userWords == nil {
+		return values
+	}
+	return append(d.userWords.SimilarItemValues(word, subs), values...)
+}
+
+// WordIsKnown reports whether word is present in dictionary or user lexicon,
+// accounting for character substitutes.
 func (d *Dictionary) WordIsKnown(word string, subs map[rune]rune) bool {
-	if len(d.words.Lookup(word)) > 0 {
+	if len(d.words.Lookup(word)) > 0 || len(d.userData[word]) > 0 {
 		return true
 	}
 	if len(subs) > 0 {
-		vals := d.words.SimilarItemValues(word, subs)
+		vals := d.SimilarItemValues(word, subs)
 		return len(vals) > 0
 	}
 	return false
 }
 
-// KnownWord holds information returned by IterKnownWords.
+// KnownWord is a known word analyzed as a single form of a paradigm.
 type KnownWord struct {
 	Word       string
 	Tag        tagset.Tag
@@ -138,18 +233,111 @@ type KnownWord struct {
 	Index      uint16
 }
 
-// IterKnownWords returns slice of known words with prefix.
+// IterKnownWords returns slice of known words with prefix, including words
+// of the user lexicon, in the order of KnownWords.
+//
+// Deprecated: use KnownWords, which doesn't keep all words in memory.
 func (d *Dictionary) IterKnownWords(prefix string) []KnownWord {
-	res := []KnownWord{}
-	for word, forms := range d.words.Data() {
-		if !strings.HasPrefix(word, prefix) {
-			continue
+	return slices.AppendSeq([]KnownWord{}, d.KnownWords(prefix))
+}
+
+// KnownWords returns an iterator over known words with prefix, including
+// words of the user lexicon, in byte order of words. A word has an entry for
+// every paradigm form it is; entries of the user lexicon come first.
+func (d *Dictionary) KnownWords(prefix string) iter.Seq[KnownWord] {
+	return func(yield func(KnownWord) bool) {
+		for word, wf := range d.wordForms(prefix) {
+			kw := KnownWord{
+				Word:       word,
+				Tag:        d.BuildTagInfo(int(wf.ParadigmID), int(wf.FormIndex)),
+				NormalForm: d.BuildNormalForm(int(wf.ParadigmID), int(wf.FormIndex), word),
+				ParadigmID: wf.ParadigmID,
+				Index:      wf.FormIndex,
+			}
+			if !yield(kw) {
+				return
+			}
+		}
+	}
+}
+
+// Lexemes returns an iterator over lexemes with normal forms starting with
+// prefix, including lexemes of the user lexicon, in byte order of normal
+// forms. A lexeme holds all forms of a word inflected by a paradigm, in the
+// paradigm order; homonyms sharing a paradigm are a single lexeme.
+func (d *Dictionary) Lexemes(prefix string) iter.Seq[[]KnownWord] {
+	return func(yield func([]KnownWord) bool) {
+		var last string
+		var seen []uint16
+		for word, wf := range d.wordForms(prefix) {
+			if wf.FormIndex != 0 {
+				continue
+			}
+			// a user lexeme may repeat a compiled one
+			if word != last {
+				last, seen = word, seen[:0]
+			}
+			if slices.Contains(seen, wf.ParadigmID) {
+				continue
+			}
+			seen = append(seen, wf.ParadigmID)
+			if !yield(d.lexeme(word, wf.ParadigmID)) {
+				return
+			}
 		}
-		for _, wf := range forms {
-			tag := d.BuildTagInfo(int(wf.ParadigmID), int(wf.FormIndex))
-			normal := d.BuildNormalForm(int(wf.ParadigmID), int(wf.FormIndex), word)
-			res = append(res, KnownWord{Word: word, Tag: tag, NormalForm: normal, ParadigmID: wf.ParadigmID, Index: wf.FormIndex})
+	}
+}
+
+// lexeme returns forms of normal form word inflected by paradigm paraID.
+func (d *Dictionary) lexeme(word string, paraID uint16) []KnownWord {
+	stem := d.BuildStem(d.paradigms[paraID], 0, word)
+	forms := d.BuildParadigmInfo(int(paraID))
+	res := make([]KnownWord, len(forms))
+	for i, f := range forms {
+		res[i] = KnownWord{
+			Word:       f.Prefix + stem + f.Suffix,
+			Tag:        f.Tag,
+			NormalForm: word,
+			ParadigmID: paraID,
+			Index:      uint16(i),
 		}
 	}
 	return res
 }
+
+// wordForms returns an iterator over words with prefix and their forms,
+// merging the user lexicon into compiled words in byte order.
+func (d *Dictionary) wordForms(prefix string) iter.Seq2[string, dawg.WordForm] {
+	return func(yield func(string, dawg.WordForm) bool) {
+		var user []string
+		for w := range d.userData {
+			if strings.HasPrefix(w, prefix) {
+				user = append(user, w)
+			}
+		}
+		slices.Sort(user)
+		emit := func(word string, forms []dawg.WordForm) bool {
+			for _, wf := range forms {
+				if !yield(word, wf) {
+					return false
+				}
+			}
+			return true
+		}
+		for word, forms := range d.words.All(prefix) {
+			for ; len(user) > 0 && user[0] <= word; user = user[1:] {
+				if !emit(user[0], d.userData[user[0]]) {
+					return
+				}
+			}
+			if !emit(word, forms) {
+				return
+			}
+		}
+		for _, w := range user {
+			if !emit(w, d.userData[w]) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package dict
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKnownWords(t *testing.T) {
+	d := openTestDict(t, saveTestDict(t, compileTestDict(t, nil, nil)))
+	// the user lexeme of "ёж" repeats the compiled one
+	lex, err := ReadLexiconTSV(strings.NewReader(testLexiconTSV + "ёж\t=ёж\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddLexicon(lex); err != nil {
+		t.Fatal(err)
+	}
+
+	var words []string
+	for w := range d.KnownWords("") {
+		words = append(words, w.Word+":"+w.NormalForm)
+	}
+	want := []string{"ежа:ёж", "ежа:ёж", "ежи:ёж", "ежи:ёж", "кот:кот", "кота:кот", "крот:крот", "крота:крот", "ёж:ёж", "ёж:ёж"}
+	if !reflect.DeepEqual(words, want) {
+		t.Errorf("KnownWords = %q, want %q", words, want)
+	}
+	words = words[:0]
+	for w := range d.KnownWords("кот") {
+		if words = append(words, w.Word); len(words) == 1 {
+			break
+		}
+	}
+	if want := []string{"кот"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("KnownWords(кот) = %q, want %q", words, want)
+	}
+
+	var lexemes []string
+	for lexeme := range d.Lexemes("") {
+		var forms []string
+		for _, w := range lexeme {
+			forms = append(forms, w.Word+" "+w.Tag.String())
+		}
+		lexemes = append(lexemes, strings.Join(forms, ", "))
+	}
+	// "ежи" is linked to "ёж"
+	want = []string{
+		"кот NOUN,anim,masc sing,nomn, кота NOUN,anim,masc sing,gent",
+		"крот NOUN,anim,masc sing,nomn, крота NOUN,anim,masc sing,gent",
+		"ёж NOUN,anim sing,nomn, ежа NOUN,anim sing,gent, ежи NOUN plur,nomn",
+	}
+	if !reflect.DeepEqual(lexemes, want) {
+		t.Errorf("Lexemes = %q, want %q", lexemes, want)
+	}
+}
@@ -0,0 +1,245 @@
+package dict
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"morphy/pkg/dawg"
+)
+
+// DictDiff describes changes between two compiled dictionaries.
+type DictDiff struct {
+	Old DiffSource `json:"old"`
+	New DiffSource `json:"new"`
+	// AddedLexemes and RemovedLexemes are lexemes identified by the normal
+	// form and its tag.
+	AddedLexemes   []DiffLexeme `json:"added_lexemes"`
+	RemovedLexemes []DiffLexeme `json:"removed_lexemes"`
+	AddedWords     []string     `json:"added_words"`
+	RemovedWords   []string     `json:"removed_words"`
+	// ChangedTags lists words present in both dictionaries whose sets of
+	// tags differ.
+	ChangedTags []TagsChange `json:"changed_tags"`
+	// MovedNormalForms lists words present in both dictionaries whose sets
+	// of normal forms differ.
+	MovedNormalForms []NormalFormsChange `json:"moved_normal_forms"`
+}
+
+// DiffSource identifies a compared dictionary and the OpenCorpora release
+// it was compiled from.
+type DiffSource struct {
+	Path     string `json:"path"`
+	Version  string `json:"version"`
+	Revision string `json:"revision"`
+}
+
+// DiffLexeme is a lexeme given by its normal form and the tag of it.
+type DiffLexeme struct {
+	NormalForm string `json:"normal_form"`
+	Tag        string `json:"tag"`
+}
+
+// TagsChange holds tags added to and removed from a word.
+type TagsChange struct {
+	Word    string   `json:"word"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// NormalFormsChange holds normal forms of a word in both dictionaries.
+type NormalFormsChange struct {
+	Word string   `json:"word"`
+	Old  []string `json:"old"`
+	New  []string `json:"new"`
+}
+
+// DiffDictionaries loads dictionaries at oldPath and newPath and compares
+// their words and paradigms.
+func DiffDictionaries(oldPath, newPath string) (*DictDiff, error) {
+	oldDict, err := NewDictionary(oldPath)
+	if err != nil {
+		return nil, err
+	}
+	defer oldDict.Close()
+	newDict, err := NewDictionary(newPath)
+	if err != nil {
+		return nil, err
+	}
+	defer newDict.Close()
+	return Diff(oldDict, newDict), nil
+}
+
+// Diff compares words and paradigms of two dictionaries. Words are walked in
+// byte order, so the lists in the result are sorted.
+func Diff(oldDict, newDict *Dictionary) *DictDiff {
+	res := &DictDiff{
+		Old:              oldDict.source(),
+		New:              newDict.source(),
+		AddedLexemes:     []DiffLexeme{},
+		RemovedLexemes:   []DiffLexeme{},
+		AddedWords:       []string{},
+		RemovedWords:     []string{},
+		ChangedTags:      []TagsChange{},
+		MovedNormalForms: []NormalFormsChange{},
+	}
+	oldLexemes := map[DiffLexeme]struct{}{}
+	oldDict.words.Walk("", func(word string, forms []dawg.WordForm) bool {
+		oldDict.addLexemes(oldLexemes, word, forms)
+		newForms := newDict.words.Lookup(word)
+		if len(newForms) == 0 {
+			res.RemovedWords = append(res.RemovedWords, word)
+			return true
+		}
+		oldTags, newTags := oldDict.wordTags(forms), newDict.wordTags(newForms)
+		if added, removed := sortedDiff(oldTags, newTags); len(added) > 0 || len(removed) > 0 {
+			res.ChangedTags = append(res.ChangedTags, TagsChange{Word: word, Added: added, Removed: removed})
+		}
+		oldNormals, newNormals := oldDict.wordNormalForms(word, forms), newDict.wordNormalForms(word, newForms)
+		if added, removed := sortedDiff(oldNormals, newNormals); len(added) > 0 || len(removed) > 0 {
+			res.MovedNormalForms = append(res.MovedNormalForms, NormalFormsChange{Word: word, Old: oldNormals, New: newNormals})
+		}
+		return true
+	})
+	newLexemes := map[DiffLexeme]struct{}{}
+	newDict.words.Walk("", func(word string, forms []dawg.WordForm) bool {
+		newDict.addLexemes(newLexemes, word, forms)
+		if len(oldDict.words.Lookup(word)) == 0 {
+			res.AddedWords = append(res.AddedWords, word)
+		}
+		return true
+	})
+	for l := range newLexemes {
+		if _, ok := oldLexemes[l]; !ok {
+			res.AddedLexemes = append(res.AddedLexemes, l)
+		}
+	}
+	for l := range oldLexemes {
+		if _, ok := newLexemes[l]; !ok {
+			res.RemovedLexemes = append(res.RemovedLexemes, l)
+		}
+	}
+	sortLexemes(res.AddedLexemes)
+	sortLexemes(res.RemovedLexemes)
+	return res
+}
+
+// WriteSummary writes a human-readable summary of the diff to w, listing at
+// most limit examples per section; limit < 0 lists everything.
+func (d *DictDiff) WriteSummary(w io.Writer, limit int) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "old: %s\nnew: %s\n", d.Old, d.New)
+	section := func(title string, n int, item func(i int) string) {
+		fmt.Fprintf(&b, "\n%s: %d\n", title, n)
+		shown := n
+		if limit >= 0 && limit < n {
+			shown = limit
+		}
+		for i := 0; i < shown; i++ {
+			fmt.Fprintf(&b, "  %s\n", item(i))
+		}
+		if shown < n {
+			fmt.Fprintf(&b, "  ... and %d more\n", n-shown)
+		}
+	}
+	section("added lexemes", len(d.AddedLexemes), func(i int) string { return d.AddedLexemes[i].String() })
+	section("removed lexemes", len(d.RemovedLexemes), func(i int) string { return d.RemovedLexemes[i].String() })
+	section("added words", len(d.AddedWords), func(i int) string { return d.AddedWords[i] })
+	section("removed words", len(d.RemovedWords), func(i int) string { return d.RemovedWords[i] })
+	section("words with changed tags", len(d.ChangedTags), func(i int) string {
+		c := d.ChangedTags[i]
+		parts := []string{c.Word}
+		for _, t := range c.Added {
+			parts = append(parts, "+"+t)
+		}
+		for _, t := range c.Removed {
+			parts = append(parts, "-"+t)
+		}
+		return strings.Join(parts, "  ")
+	})
+	section("words with moved normal forms", len(d.MovedNormalForms), func(i int) string {
+		c := d.MovedNormalForms[i]
+		return fmt.Sprintf("%s: %s -> %s", c.Word, strings.Join(c.Old, ", "), strings.Join(c.New, ", "))
+	})
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (s DiffSource) String() string {
+	if s.Version == "" && s.Revision == "" {
+		return s.Path
+	}
+	return fmt.Sprintf("%s (OpenCorpora %s, revision %s)", s.Path, s.Version, s.Revision)
+}
+
+func (l DiffLexeme) String() string { return l.NormalForm + " " + l.Tag }
+
+// source returns the path and OpenCorpora release recorded in meta.
+func (d *Dictionary) source() DiffSource {
+	s := DiffSource{Path: d.path}
+	s.Version, _ = d.meta["source_version"].(string)
+	s.Revision, _ = d.meta["source_revision"].(string)
+	return s
+}
+
+func (d *Dictionary) addLexemes(lexemes map[DiffLexeme]struct{}, word string, forms []dawg.WordForm) {
+	for _, wf := range forms {
+		if wf.FormIndex != 0 {
+			continue
+		}
+		tag := d.BuildTagInfo(int(wf.ParadigmID), 0)
+		lexemes[DiffLexeme{NormalForm: word, Tag: tag.String()}] = struct{}{}
+	}
+}
+
+func (d *Dictionary) wordTags(forms []dawg.WordForm) []string {
+	tags := make([]string, 0, len(forms))
+	for _, wf := range forms {
+		tag := d.BuildTagInfo(int(wf.ParadigmID), int(wf.FormIndex))
+		tags = append(tags, tag.String())
+	}
+	return sortedSet(tags)
+}
+
+func (d *Dictionary) wordNormalForms(word string, forms []dawg.WordForm) []string {
+	normals := make([]string, 0, len(forms))
+	for _, wf := range forms {
+		normals = append(normals, d.BuildNormalForm(int(wf.ParadigmID), int(wf.FormIndex), word))
+	}
+	return sortedSet(normals)
+}
+
+func sortedSet(items []string) []string {
+	sort.Strings(items)
+	return slices.Compact(items)
+}
+
+// sortedDiff returns items of sorted sets present only in b and only in a.
+func sortedDiff(a, b []string) (added, removed []string) {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || i < len(a) && a[i] < b[j]:
+			removed = append(removed, a[i])
+			i++
+		case i == len(a) || b[j] < a[i]:
+			added = append(added, b[j])
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	return added, removed
+}
+
+func sortLexemes(ls []DiffLexeme) {
+	sort.Slice(ls, func(i, j int) bool {
+		if ls[i].NormalForm != ls[j].NormalForm {
+			return ls[i].NormalForm < ls[j].NormalForm
+		}
+		return ls[i].Tag < ls[j].Tag
+	})
+}
@@ -0,0 +1,53 @@
+package dict
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	open := func(revision string, lexemes map[string][]WordForm) *Dictionary {
+		parsed := &ParsedDictionary{Lexemes: lexemes, Version: "0.92", Revision: revision}
+		return openTestDict(t, saveTestDict(t, compileTestDict(t, parsed, nil)))
+	}
+	oldDict := open("1", map[string][]WordForm{
+		"1": {{"ёж", "NOUN,anim,masc sing,nomn"}, {"ежа", "NOUN,anim,masc sing,gent"}},
+		"2": {{"стали", "VERB,perf,intr plur,past,indc"}},
+		"3": {{"кот", "NOUN,anim,masc sing,nomn"}},
+	})
+	newDict := open("2", map[string][]WordForm{
+		"1": {{"ёж", "NOUN,anim,masc sing,nomn"}, {"ежа", "NOUN,anim,masc sing,gent"}},
+		"2": {{"стать", "VERB,perf,intr INFN"}, {"стали", "VERB,perf,intr plur,past,indc"}},
+		"4": {{"уж", "NOUN,anim,masc sing,nomn"}},
+	})
+	diff := Diff(oldDict, newDict)
+	if diff.Old.Revision != "1" || diff.New.Revision != "2" {
+		t.Errorf("sources = %v, %v", diff.Old, diff.New)
+	}
+	wantAdded := []DiffLexeme{{"стать", "VERB,perf,intr INFN"}, {"уж", "NOUN,anim,masc sing,nomn"}}
+	if !reflect.DeepEqual(diff.AddedLexemes, wantAdded) {
+		t.Errorf("added lexemes = %v, want %v", diff.AddedLexemes, wantAdded)
+	}
+	wantRemoved := []DiffLexeme{{"кот", "NOUN,anim,masc sing,nomn"}, {"стали", "VERB,perf,intr plur,past,indc"}}
+	if !reflect.DeepEqual(diff.RemovedLexemes, wantRemoved) {
+		t.Errorf("removed lexemes = %v, want %v", diff.RemovedLexemes, wantRemoved)
+	}
+	if !reflect.DeepEqual(diff.AddedWords, []string{"стать", "уж"}) || !reflect.DeepEqual(diff.RemovedWords, []string{"кот"}) {
+		t.Errorf("words = +%v -%v", diff.AddedWords, diff.RemovedWords)
+	}
+	if len(diff.ChangedTags) != 0 {
+		t.Errorf("changed tags = %v", diff.ChangedTags)
+	}
+	wantMoved := []NormalFormsChange{{Word: "стали", Old: []string{"стали"}, New: []string{"стать"}}}
+	if !reflect.DeepEqual(diff.MovedNormalForms, wantMoved) {
+		t.Errorf("moved normal forms = %v, want %v", diff.MovedNormalForms, wantMoved)
+	}
+	var b strings.Builder
+	if err := diff.WriteSummary(&b, 1); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "added lexemes: 2\n  стать VERB,perf,intr INFN\n  ... and 1 more") {
+		t.Errorf("unexpected summary:\n%s", b.String())
+	}
+}
@@ -0,0 +1,124 @@
+package dict
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"morphy/pkg/tagset"
+)
+
+// Export formats supported by Export.
+const (
+	// ExportTSV writes "word<TAB>normal form<TAB>tag" lines.
+	ExportTSV = "tsv"
+	// ExportUniMorph writes "lemma<TAB>word<TAB>features" lines with
+	// UniMorph feature bundles.
+	ExportUniMorph = "unimorph"
+)
+
+// ExportOptions configures Export.
+type ExportOptions struct {
+	// Format is ExportTSV (default) or ExportUniMorph.
+	Format string
+	// POS keeps entries of these parts of speech only.
+	POS []string
+	// Grammemes keeps entries whose tags have all of these grammemes.
+	Grammemes []string
+}
+
+// Export streams known words matching opts to w in the order of
+// KnownWords, one line per paradigm form of a word.
+func Export(w io.Writer, d *Dictionary, opts ExportOptions) error {
+	format := opts.Format
+	if format == "" {
+		format = ExportTSV
+	}
+	if format != ExportTSV && format != ExportUniMorph {
+		return fmt.Errorf("unknown export format %q", format)
+	}
+	for _, g := range slices.Concat(opts.POS, opts.Grammemes) {
+		if !tagset.GrammemeIsKnown(g) {
+			return fmt.Errorf("unknown grammeme %q", g)
+		}
+	}
+	pos := make(map[string]bool, len(opts.POS))
+	for _, p := range opts.POS {
+		pos[p] = true
+	}
+	bw := bufio.NewWriter(w)
+	for kw := range d.KnownWords("") {
+		if len(pos) > 0 && !pos[kw.Tag.POS()] || !tagHasAll(&kw.Tag, opts.Grammemes) {
+			continue
+		}
+		var err error
+		if format == ExportUniMorph {
+			_, err = fmt.Fprintf(bw, "%s\t%s\t%s\n", kw.NormalForm, kw.Word, UniMorphFeatures(&kw.Tag))
+		} else {
+			_, err = fmt.Fprintf(bw, "%s\t%s\t%s\n", kw.Word, kw.NormalForm, kw.Tag.String())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// uniMorphPOS maps OpenCorpora parts of speech to UniMorph ones; some of
+// them also add features.
+var uniMorphPOS = map[string][]string{
+	"NOUN": {"N"},
+	"ADJF": {"ADJ"},
+	"ADJS": {"ADJ"},
+	"COMP": {"ADJ", "CMPR"},
+	"VERB": {"V"},
+	"INFN": {"V", "NFIN"},
+	"PRTF": {"V.PTCP"},
+	"PRTS": {"V.PTCP"},
+	"GRND": {"V.CVB"},
+	"NUMR": {"NUM"},
+	"ADVB": {"ADV"},
+	"NPRO": {"PRO"},
+	"PRED": {"ADV"},
+	"PREP": {"ADP"},
+	"CONJ": {"CONJ"},
+	"PRCL": {"PART"},
+	"INTJ": {"INTJ"},
+}
+
+// uniMorphFeatures maps OpenCorpora grammemes to UniMorph features, in the
+// order features are written.
+var uniMorphFeatures = []struct{ gram, feature string }{
+	{"perf", "PFV"}, {"impf", "IPFV"},
+	{"indc", "IND"}, {"impr", "IMP"},
+	{"past", "PST"}, {"pres", "PRS"}, {"futr", "FUT"},
+	{"actv", "ACT"}, {"pssv", "PASS"},
+	{"1per", "1"}, {"2per", "2"}, {"3per", "3"},
+	{"nomn", "NOM"}, {"gent", "GEN"}, {"datv", "DAT"}, {"accs", "ACC"},
+	{"ablt", "INS"}, {"loct", "ESS"}, {"voct", "VOC"}, {"gen2", "PRT"},
+	{"acc2", "ACC"}, {"loc2", "ESS"},
+	{"anim", "ANIM"}, {"inan", "INAN"},
+	{"masc", "MASC"}, {"femn", "FEM"}, {"neut", "NEUT"},
+	{"sing", "SG"}, {"plur", "PL"},
+	{"Supr", "SPRL"}, {"Cmp2", "CMPR"},
+}
+
+// UniMorphFeatures converts tag to a UniMorph feature bundle, e.g.
+// "NOUN,anim,femn sing,gent" to "N;GEN;ANIM;FEM;SG". Grammemes without a
+// UniMorph counterpart are dropped.
+func UniMorphFeatures(tag *tagset.Tag) string {
+	features := append([]string(nil), uniMorphPOS[tag.POS()]...)
+	seen := map[string]bool{}
+	for _, f := range features {
+		seen[f] = true
+	}
+	for _, m := range uniMorphFeatures {
+		if ok, _ := tag.Contains(m.gram); ok && !seen[m.feature] {
+			seen[m.feature] = true
+			features = append(features, m.feature)
+		}
+	}
+	return strings.Join(features, ";")
+}
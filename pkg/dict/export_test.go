@@ -0,0 +1,46 @@
+package dict
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	d := openTestDict(t, saveTestDict(t, compileTestDict(t, nil, nil)))
+	var b strings.Builder
+	if err := Export(&b, d, ExportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "ежа\tёж\tNOUN,anim sing,gent\nежи\tёж\tNOUN plur,nomn\nёж\tёж\tNOUN,anim sing,nomn\n"
+	if b.String() != want {
+		t.Errorf("tsv export:\n%s\nwant:\n%s", b.String(), want)
+	}
+	b.Reset()
+	if err := Export(&b, d, ExportOptions{Format: ExportUniMorph, Grammemes: []string{"sing"}}); err != nil {
+		t.Fatal(err)
+	}
+	want = "ёж\tежа\tN;GEN;ANIM;SG\nёж\tёж\tN;NOM;ANIM;SG\n"
+	if b.String() != want {
+		t.Errorf("unimorph export:\n%s\nwant:\n%s", b.String(), want)
+	}
+	if err := Export(&b, d, ExportOptions{POS: []string{"NOPE"}}); err == nil {
+		t.Errorf("expected error for unknown POS")
+	}
+
+	// words of the user lexicon are exported too
+	lex, err := ReadLexiconTSV(strings.NewReader(testLexiconTSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddLexicon(lex); err != nil {
+		t.Fatal(err)
+	}
+	b.Reset()
+	if err := Export(&b, d, ExportOptions{Grammemes: []string{"gent"}}); err != nil {
+		t.Fatal(err)
+	}
+	want = "ежа\tёж\tNOUN,anim sing,gent\nкота\tкот\tNOUN,anim,masc sing,gent\nкрота\tкрот\tNOUN,anim,masc sing,gent\n"
+	if b.String() != want {
+		t.Errorf("export with user lexicon:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,85 @@
+package dict
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+// testDictXML is the OpenCorpora dictionary the tests of the package are
+// built from.
+const testDictXML = `<?xml version="1.0" encoding="utf-8" standalone="yes"?>
+<dictionary version="0.92" revision="389440">
+<grammemes>
+<grammeme parent=""><name>NOUN</name><alias>СУЩ</alias><description>имя существительное</description></grammeme>
+<grammeme parent="NOUN"><name>anim</name><alias>од</alias><description>одушевлённое</description></grammeme>
+</grammemes>
+<restrictions>
+<restr type="maybe" auto="0"><left type="lemma">NOUN</left><right type="lemma">anim</right></restr>
+</restrictions>
+<lemmata>
+<lemma id="1" rev="1"><l t="ёж"><g v="NOUN"/><g v="anim"/></l><f t="ёж"><g v="sing"/><g v="nomn"/></f><f t="ЕЖА"><g v="sing"/><g v="gent"/></f></lemma>
+<lemma id="2" rev="2"><l t="ежи"><g v="NOUN"/></l><f t="ежи"><g v="plur"/><g v="nomn"/></f></lemma>
+</lemmata>
+<link_types><type id="10">SURN_MASC-SURN_PLUR</type></link_types>
+<links><link id="1" from="1" to="2" type="10"/></links>
+</dictionary>`
+
+// parseTestDict parses testDictXML.
+func parseTestDict(t *testing.T) *ParsedDictionary {
+	t.Helper()
+	parsed := &ParsedDictionary{Lexemes: map[string][]WordForm{}}
+	err := StreamOpencorporaXML(strings.NewReader(testDictXML), OpencorporaHandler{
+		Lemma: func(id string, forms []WordForm) error {
+			parsed.Lexemes[id] = forms
+			return nil
+		},
+		Link: func(l Link) error {
+			parsed.Links = append(parsed.Links, l)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+// compileTestDict compiles parsed, or testDictXML if parsed is nil. Options
+// are added to "min_paradigm_popularity": 1, so that paradigms of single
+// lexemes are used for prediction.
+func compileTestDict(t *testing.T, parsed *ParsedDictionary, options map[string]any) *CompiledDictionary {
+	t.Helper()
+	if parsed == nil {
+		parsed = parseTestDict(t)
+	}
+	opts := map[string]any{"min_paradigm_popularity": 1}
+	maps.Copy(opts, options)
+	compiled, err := CompileParsedDict(parsed, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return compiled
+}
+
+// saveTestDict saves compiled in the JSON layout to a temporary directory
+// and returns its path.
+func saveTestDict(t *testing.T, compiled *CompiledDictionary) string {
+	t.Helper()
+	path := t.TempDir()
+	if err := SaveCompiledDict(compiled, path, "test", "ru"); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// openTestDict loads dictionary at path; it is closed when the test ends.
+func openTestDict(t *testing.T, path string) *Dictionary {
+	t.Helper()
+	d, err := NewDictionary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
@@ -0,0 +1,496 @@
+package dict
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"morphy/pkg/tagset"
+)
+
+// HunspellOptions configures conversion of Hunspell data to tags. Grammemes
+// are given as comma-separated lists. The JSON form is read by the
+// "morphy import-hunspell" command.
+type HunspellOptions struct {
+	// Morph maps morphological fields, e.g. "po:noun" or "is:plural", to
+	// grammemes. An unmapped field whose value is a known grammeme (e.g.
+	// "po:NOUN") adds that grammeme; other unmapped fields are ignored.
+	Morph map[string]string `json:"morph"`
+	// LexemeFlags maps flags of dictionary words to grammemes of the whole
+	// lexeme, e.g. a flag of a noun declension to "NOUN".
+	LexemeFlags map[string]string `json:"lexeme_flags"`
+	// AffixFlags maps affix flags to grammemes of forms built with them.
+	AffixFlags map[string]string `json:"affix_flags"`
+	// BaseGrammemes are grammemes of the dictionary word form itself, e.g.
+	// "sing,nomn".
+	BaseGrammemes string `json:"base_grammemes"`
+}
+
+// ImportHunspell reads Hunspell dictionary (.dic) and affix (.aff) files.
+// See ReadHunspell.
+func ImportHunspell(dicPath, affPath string, opts HunspellOptions) (*ParsedDictionary, error) {
+	aff, err := os.Open(affPath)
+	if err != nil {
+		return nil, err
+	}
+	defer aff.Close()
+	dic, err := os.Open(dicPath)
+	if err != nil {
+		return nil, err
+	}
+	defer dic.Close()
+	return ReadHunspell(dic, aff, opts)
+}
+
+// ReadHunspell expands every dictionary word with its affix rules into a
+// lexeme. The word itself is the normal form unless it has the NEEDAFFIX
+// flag. Tags consist of lexeme grammemes (LexemeFlags and morphological
+// fields of the word except is: and ip:) followed by form grammemes
+// (BaseGrammemes and is:/ip: fields of the word for the word itself,
+// AffixFlags and morphological fields of affix rules for derived forms).
+// Prefixes and suffixes are combined when both allow cross products; suffix
+// continuation classes are applied once. Only UTF-8 files are supported.
+//
+// Every grammeme used is listed in Grammemes of the result, so the
+// dictionary compiled from it registers them when loaded. Lexemes are
+// numbered in the order of their words, so the "lemma_ids" compile option
+// must not be set for the result.
+func ReadHunspell(dic, aff io.Reader, opts HunspellOptions) (*ParsedDictionary, error) {
+	a, err := readHunspellAff(aff)
+	if err != nil {
+		return nil, err
+	}
+	pd := &ParsedDictionary{
+		Lexemes:      map[string][]WordForm{},
+		Links:        []Link{},
+		Grammemes:    []Grammeme{},
+		Restrictions: []Restriction{},
+	}
+	used := map[string]bool{}
+	sc := bufio.NewScanner(dic)
+	sc.Buffer(nil, 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if n == 1 {
+			if _, err := strconv.Atoi(line); err == nil {
+				continue
+			}
+		}
+		if !utf8.ValidString(line) {
+			return nil, fmt.Errorf("dic line %d: invalid UTF-8", n)
+		}
+		forms, err := a.expand(line, opts)
+		if err != nil {
+			return nil, fmt.Errorf("dic line %d: %w", n, err)
+		}
+		if len(forms) == 0 {
+			continue
+		}
+		for _, f := range forms {
+			for _, g := range parseTagGrammemes(f.Tag) {
+				used[g] = true
+			}
+		}
+		pd.Lexemes[strconv.Itoa(len(pd.Lexemes)+1)] = forms
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(used))
+	for g := range used {
+		names = append(names, g)
+	}
+	slices.Sort(names)
+	for _, g := range names {
+		pd.Grammemes = append(pd.Grammemes, Grammeme{Name: g})
+	}
+	return pd, nil
+}
+
+type hunspellAff struct {
+	flagType     string
+	needAffix    string
+	forbidden    string
+	flagAliases  [][]string
+	morphAliases [][]string
+	affixes      map[string]*affixClass
+}
+
+type affixClass struct {
+	prefix bool
+	cross  bool
+	rules  []affixRule
+}
+
+type affixRule struct {
+	strip string
+	add   string
+	cont  []string
+	cond  []charClass
+	morph []string
+}
+
+// charClass is a single position of an affix condition: any character, a
+// set of characters or a negated set.
+type charClass struct {
+	any   bool
+	neg   bool
+	chars string
+}
+
+func (c charClass) match(r rune) bool {
+	if c.any {
+		return true
+	}
+	return strings.ContainsRune(c.chars, r) != c.neg
+}
+
+func readHunspellAff(r io.Reader) (*hunspellAff, error) {
+	a := &hunspellAff{affixes: map[string]*affixClass{}}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		errorf := func(format string, args ...any) error {
+			return fmt.Errorf("aff line %d: %s", n, fmt.Sprintf(format, args...))
+		}
+		switch fields[0] {
+		case "SET":
+			if len(fields) > 1 && !strings.EqualFold(fields[1], "UTF-8") {
+				return nil, errorf("unsupported encoding %s", fields[1])
+			}
+		case "FLAG":
+			if len(fields) > 1 {
+				a.flagType = fields[1]
+			}
+		case "NEEDAFFIX":
+			if len(fields) > 1 {
+				a.needAffix = fields[1]
+			}
+		case "FORBIDDENWORD":
+			if len(fields) > 1 {
+				a.forbidden = fields[1]
+			}
+		case "AF":
+			// the first AF line holds the number of aliases
+			if len(fields) > 1 {
+				if _, err := strconv.Atoi(fields[1]); err == nil && a.flagAliases == nil {
+					a.flagAliases = [][]string{}
+					continue
+				}
+				a.flagAliases = append(a.flagAliases, a.splitFlags(fields[1]))
+			}
+		case "AM":
+			if len(fields) > 1 {
+				if _, err := strconv.Atoi(fields[1]); err == nil && len(fields) == 2 && a.morphAliases == nil {
+					a.morphAliases = [][]string{}
+					continue
+				}
+				a.morphAliases = append(a.morphAliases, fields[1:])
+			}
+		case "PFX", "SFX":
+			if len(fields) < 4 {
+				return nil, errorf("malformed %s", fields[0])
+			}
+			flag := fields[1]
+			class, ok := a.affixes[flag]
+			if !ok {
+				// the header: flag, cross product, number of rules
+				a.affixes[flag] = &affixClass{prefix: fields[0] == "PFX", cross: fields[2] == "Y"}
+				continue
+			}
+			rule, err := a.parseRule(fields[2:])
+			if err != nil {
+				return nil, errorf("%v", err)
+			}
+			class.rules = append(class.rules, rule)
+		}
+	}
+	return a, sc.Err()
+}
+
+// parseRule parses "strip add[/flags] condition [morphology...]" fields.
+func (a *hunspellAff) parseRule(fields []string) (affixRule, error) {
+	var rule affixRule
+	if fields[0] != "0" {
+		rule.strip = fields[0]
+	}
+	add, cont, _ := strings.Cut(fields[1], "/")
+	if add != "0" {
+		rule.add = add
+	}
+	if cont != "" {
+		rule.cont = a.flags(cont)
+	}
+	cond := "."
+	if len(fields) > 2 {
+		cond = fields[2]
+	}
+	var err error
+	if rule.cond, err = parseCondition(cond); err != nil {
+		return rule, err
+	}
+	if len(fields) > 3 {
+		rule.morph = a.morph(fields[3:])
+	}
+	return rule, nil
+}
+
+func parseCondition(cond string) ([]charClass, error) {
+	if cond == "." {
+		return nil, nil
+	}
+	var res []charClass
+	runes := []rune(cond)
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '.':
+			res = append(res, charClass{any: true})
+		case '[':
+			end := slices.Index(runes[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated condition %q", cond)
+			}
+			set := runes[i+1 : i+end]
+			c := charClass{}
+			if len(set) > 0 && set[0] == '^' {
+				c.neg, set = true, set[1:]
+			}
+			c.chars = string(set)
+			res = append(res, c)
+			i += end
+		default:
+			res = append(res, charClass{chars: string(runes[i])})
+		}
+	}
+	return res, nil
+}
+
+// splitFlags splits a flag string according to the FLAG setting.
+func (a *hunspellAff) splitFlags(s string) []string {
+	switch a.flagType {
+	case "long":
+		runes := []rune(s)
+		res := make([]string, 0, len(runes)/2)
+		for i := 0; i+1 < len(runes); i += 2 {
+			res = append(res, string(runes[i:i+2]))
+		}
+		return res
+	case "num":
+		return strings.Split(s, ",")
+	default:
+		res := make([]string, 0, len(s))
+		for _, r := range s {
+			res = append(res, string(r))
+		}
+		return res
+	}
+}
+
+// flags returns flags of a word or rule, resolving AF aliases.
+func (a *hunspellAff) flags(s string) []string {
+	if a.flagAliases != nil {
+		if i, err := strconv.Atoi(s); err == nil && i > 0 && i <= len(a.flagAliases) {
+			return a.flagAliases[i-1]
+		}
+	}
+	return a.splitFlags(s)
+}
+
+// morph returns morphological fields, resolving AM aliases.
+func (a *hunspellAff) morph(fields []string) []string {
+	if a.morphAliases != nil && len(fields) == 1 {
+		if i, err := strconv.Atoi(fields[0]); err == nil && i > 0 && i <= len(a.morphAliases) {
+			return a.morphAliases[i-1]
+		}
+	}
+	return fields
+}
+
+// apply returns word with the affix rule applied.
+func (c *affixClass) apply(rule affixRule, word string) (string, bool) {
+	runes := []rune(word)
+	if len(rule.cond) > len(runes) {
+		return "", false
+	}
+	for i, cc := range rule.cond {
+		r := runes[i]
+		if !c.prefix {
+			r = runes[len(runes)-len(rule.cond)+i]
+		}
+		if !cc.match(r) {
+			return "", false
+		}
+	}
+	if c.prefix {
+		if !strings.HasPrefix(word, rule.strip) {
+			return "", false
+		}
+		return rule.add + word[len(rule.strip):], true
+	}
+	if !strings.HasSuffix(word, rule.strip) || len(rule.strip) == len(word) && rule.add == "" {
+		return "", false
+	}
+	return word[:len(word)-len(rule.strip)] + rule.add, true
+}
+
+type hunspellForm struct {
+	word  string
+	grams []string
+	cross bool
+}
+
+// expand returns forms of a dictionary line "word[/flags] [morphology...]".
+func (a *hunspellAff) expand(line string, opts HunspellOptions) ([]WordForm, error) {
+	fields := strings.Fields(line)
+	word, flagStr := splitDicWord(fields[0])
+	if word == "" {
+		return nil, fmt.Errorf("empty word")
+	}
+	var flags []string
+	if flagStr != "" {
+		flags = a.flags(flagStr)
+	}
+	if a.forbidden != "" && slices.Contains(flags, a.forbidden) {
+		return nil, nil
+	}
+	var lexGrams, baseGrams []string
+	for _, f := range flags {
+		lexGrams = append(lexGrams, parseTagGrammemes(opts.LexemeFlags[f])...)
+	}
+	baseGrams = append(baseGrams, parseTagGrammemes(opts.BaseGrammemes)...)
+	for _, m := range a.morph(fields[1:]) {
+		if strings.HasPrefix(m, "is:") || strings.HasPrefix(m, "ip:") {
+			baseGrams = append(baseGrams, morphGrammemes(m, opts)...)
+		} else {
+			lexGrams = append(lexGrams, morphGrammemes(m, opts)...)
+		}
+	}
+
+	var forms []hunspellForm
+	if a.needAffix == "" || !slices.Contains(flags, a.needAffix) {
+		forms = append(forms, hunspellForm{word: word, grams: baseGrams})
+	}
+	var suffixed []hunspellForm
+	for _, f := range flags {
+		class, ok := a.affixes[f]
+		if !ok || class.prefix {
+			continue
+		}
+		for _, rule := range class.rules {
+			w, ok := class.apply(rule, word)
+			if !ok {
+				continue
+			}
+			sf := hunspellForm{word: w, grams: a.ruleGrammemes(f, rule, opts), cross: class.cross}
+			suffixed = append(suffixed, sf)
+			// continuation classes are applied to the suffixed word once
+			for _, cf := range rule.cont {
+				cc, ok := a.affixes[cf]
+				if !ok || cc.prefix {
+					continue
+				}
+				for _, cr := range cc.rules {
+					if w2, ok := cc.apply(cr, w); ok {
+						grams := append(slices.Clone(sf.grams), a.ruleGrammemes(cf, cr, opts)...)
+						suffixed = append(suffixed, hunspellForm{word: w2, grams: grams, cross: sf.cross && cc.cross})
+					}
+				}
+			}
+		}
+	}
+	forms = append(forms, suffixed...)
+	for _, f := range flags {
+		class, ok := a.affixes[f]
+		if !ok || !class.prefix {
+			continue
+		}
+		for _, rule := range class.rules {
+			w, ok := class.apply(rule, word)
+			if !ok {
+				continue
+			}
+			grams := a.ruleGrammemes(f, rule, opts)
+			forms = append(forms, hunspellForm{word: w, grams: grams})
+			if !class.cross {
+				continue
+			}
+			for _, sf := range suffixed {
+				if !sf.cross || !strings.HasPrefix(sf.word, rule.strip) {
+					continue
+				}
+				w := rule.add + sf.word[len(rule.strip):]
+				forms = append(forms, hunspellForm{word: w, grams: append(slices.Clone(grams), sf.grams...)})
+			}
+		}
+	}
+
+	res := make([]WordForm, 0, len(forms))
+	for _, f := range forms {
+		wf := WordForm{Word: strings.ToLower(f.word), Tag: hunspellTag(lexGrams, f.grams)}
+		if !slices.Contains(res, wf) {
+			res = append(res, wf)
+		}
+	}
+	return res, nil
+}
+
+func (a *hunspellAff) ruleGrammemes(flag string, rule affixRule, opts HunspellOptions) []string {
+	grams := parseTagGrammemes(opts.AffixFlags[flag])
+	for _, m := range rule.morph {
+		grams = append(grams, morphGrammemes(m, opts)...)
+	}
+	return grams
+}
+
+// splitDicWord splits "word/flags" at the first unescaped slash.
+func splitDicWord(s string) (word, flags string) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '/':
+			return strings.ReplaceAll(s[:i], `\/`, "/"), s[i+1:]
+		}
+	}
+	return strings.ReplaceAll(s, `\/`, "/"), ""
+}
+
+func morphGrammemes(field string, opts HunspellOptions) []string {
+	if grams, ok := opts.Morph[field]; ok {
+		return parseTagGrammemes(grams)
+	}
+	if _, value, ok := strings.Cut(field, ":"); ok && tagset.GrammemeIsKnown(value) {
+		return []string{value}
+	}
+	return nil
+}
+
+// hunspellTag builds an OpenCorpora-like tag: lexeme grammemes, a space and
+// form grammemes, without repetitions.
+func hunspellTag(lexGrams, formGrams []string) string {
+	seen := map[string]bool{}
+	uniq := func(grams []string) []string {
+		res := make([]string, 0, len(grams))
+		for _, g := range grams {
+			if !seen[g] {
+				seen[g] = true
+				res = append(res, g)
+			}
+		}
+		return res
+	}
+	lex, form := uniq(lexGrams), uniq(formGrams)
+	return strings.TrimSpace(strings.Join(lex, ",") + " " + strings.Join(form, ","))
+}
@@ -0,0 +1,69 @@
+package dict
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testHunspellAff = `SET UTF-8
+NEEDAFFIX X
+
+SFX A Y 3
+SFX A а ы [^кгх]а is:gent
+SFX A а и [кгх]а is:gent
+SFX A а у а is:accs
+
+PFX P Y 1
+PFX P 0 пра . ds:pra
+
+SFX B N 1
+SFX B 0 ы/C . is:plur
+
+SFX C N 1
+SFX C ы ами ы is:ablt
+`
+
+const testHunspellDic = `3
+мама/AP po:noun
+рука/A po:noun
+кот/BX
+`
+
+func TestReadHunspell(t *testing.T) {
+	opts := HunspellOptions{
+		Morph:         map[string]string{"po:noun": "NOUN,femn", "is:gent": "sing,gent", "is:accs": "sing,accs"},
+		LexemeFlags:   map[string]string{"B": "NOUN,masc"},
+		AffixFlags:    map[string]string{"C": "plur"},
+		BaseGrammemes: "sing,nomn",
+	}
+	pd, err := ReadHunspell(strings.NewReader(testHunspellDic), strings.NewReader(testHunspellAff), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]WordForm{
+		"1": {
+			{"мама", "NOUN,femn sing,nomn"},
+			{"мамы", "NOUN,femn sing,gent"},
+			{"маму", "NOUN,femn sing,accs"},
+			{"прамама", "NOUN,femn"},
+			{"прамамы", "NOUN,femn sing,gent"},
+			{"прамаму", "NOUN,femn sing,accs"},
+		},
+		"2": {
+			{"рука", "NOUN,femn sing,nomn"},
+			{"руки", "NOUN,femn sing,gent"},
+			{"руку", "NOUN,femn sing,accs"},
+		},
+		"3": {
+			{"коты", "NOUN,masc plur"},
+			{"котами", "NOUN,masc plur,ablt"},
+		},
+	}
+	if !reflect.DeepEqual(pd.Lexemes, want) {
+		t.Errorf("lexemes = %v, want %v", pd.Lexemes, want)
+	}
+	if _, err := CompileParsedDict(pd, nil); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,344 @@
+package dict
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"morphy/pkg/dawg"
+	"morphy/pkg/tagset"
+)
+
+// Lexicon is a set of user lexemes added on top of a compiled Dictionary at
+// runtime (see Dictionary.AddLexicon).
+type Lexicon struct {
+	Entries []LexiconEntry
+}
+
+// LexiconEntry declares a user lexeme. Either Forms lists all forms of the
+// lexeme, the first one being the normal form, or Lemma is inflected like
+// the existing word Like. LikeTag optionally selects a lexeme of Like by
+// grammemes of its normal form, e.g. "NOUN" or "VERB,perf".
+type LexiconEntry struct {
+	Forms   []WordForm
+	Lemma   string
+	Like    string
+	LikeTag string
+}
+
+// LoadLexicon reads a user lexicon from path. Files with the ".xml"
+// extension are read with ReadLexiconXML, others with ReadLexiconTSV.
+func LoadLexicon(path string) (*Lexicon, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(path), ".xml") {
+		return ReadLexiconXML(f)
+	}
+	return ReadLexiconTSV(f)
+}
+
+// ReadLexiconTSV reads a user lexicon in the tab-separated format:
+//
+//	# a full lexeme: "form<TAB>tag" lines ended by an empty line
+//	яндекс	NOUN,inan,masc,Orgn sing,nomn
+//	яндекса	NOUN,inan,masc,Orgn sing,gent
+//
+//	# a lemma inflected like an existing word, optionally of a given tag
+//	гуглить	=искать
+//	смузи	=кофе	NOUN
+//
+// Lines starting with '#' are comments.
+func ReadLexiconTSV(r io.Reader) (*Lexicon, error) {
+	lex := &Lexicon{}
+	var forms []WordForm
+	flush := func() {
+		if len(forms) > 0 {
+			lex.Entries = append(lex.Entries, LexiconEntry{Forms: forms})
+			forms = nil
+		}
+	}
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			flush()
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 || fields[0] == "" || fields[1] == "" {
+			return nil, fmt.Errorf("lexicon line %d: expected word and tag separated by a tab", n)
+		}
+		word := strings.ToLower(strings.TrimSpace(fields[0]))
+		if like, ok := strings.CutPrefix(fields[1], "="); ok {
+			flush()
+			e := LexiconEntry{Lemma: word, Like: strings.ToLower(strings.TrimSpace(like))}
+			if len(fields) > 2 {
+				e.LikeTag = strings.TrimSpace(fields[2])
+			}
+			lex.Entries = append(lex.Entries, e)
+			continue
+		}
+		forms = append(forms, WordForm{Word: word, Tag: strings.TrimSpace(fields[1])})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return lex, nil
+}
+
+type xmlUserLemma struct {
+	Like    string `xml:"like,attr"`
+	LikeTag string `xml:"like-tag,attr"`
+	L       struct {
+		T string `xml:"t,attr"`
+		xmlGrams
+	} `xml:"l"`
+	Fs []struct {
+		T string `xml:"t,attr"`
+		xmlGrams
+	} `xml:"f"`
+}
+
+// ReadLexiconXML reads a user lexicon from a fragment of OpenCorpora XML
+// dictionary: every <lemma> element found is a full lexeme. A lemma with the
+// "like" attribute (and optional "like-tag") is inflected like an existing
+// word instead:
+//
+//	<lemma like="искать"><l t="гуглить"/></lemma>
+func ReadLexiconXML(r io.Reader) (*Lexicon, error) {
+	lex := &Lexicon{}
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return lex, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok || se.Name.Local != "lemma" {
+			continue
+		}
+		var l xmlUserLemma
+		if err := dec.DecodeElement(&l, &se); err != nil {
+			return nil, err
+		}
+		if l.Like != "" {
+			lex.Entries = append(lex.Entries, LexiconEntry{
+				Lemma:   strings.ToLower(l.L.T),
+				Like:    strings.ToLower(l.Like),
+				LikeTag: l.LikeTag,
+			})
+			continue
+		}
+		forms := lemmaForms(xmlLemma{L: l.L.xmlGrams, Fs: l.Fs})
+		if len(forms) > 0 {
+			lex.Entries = append(lex.Entries, LexiconEntry{Forms: forms})
+		}
+	}
+}
+
+// AddLexicon adds user lexemes to the dictionary. User words are looked up
+// before compiled ones, so their parses come first. Lexemes with new
+// paradigms extend paradigm tables; lexemes inflected like an existing word
+// share its paradigm. Either all entries are added or, on error, none.
+//
+// AddLexicon must not be called concurrently with other methods.
+func (d *Dictionary) AddLexicon(lex *Lexicon) error {
+	o := d.newOverlay()
+	for i, e := range lex.Entries {
+		var err error
+		if e.Like != "" {
+			err = o.addLike(e)
+		} else {
+			err = o.addForms(e.Forms)
+		}
+		if err != nil {
+			return fmt.Errorf("lexicon entry %d: %w", i+1, err)
+		}
+	}
+	d.paradigms = o.paradigms
+	d.gramtab = o.gramtab
+	d.suffixes = o.suffixes
+	d.userData = o.words
+	d.userWords = dawg.NewWordsDawg(o.words)
+	return nil
+}
+
+// overlay accumulates lexicon changes on copies of dictionary tables.
+type overlay struct {
+	d           *Dictionary
+	paradigms   [][]uint16
+	gramtab     []tagset.Tag
+	suffixes    []string
+	words       map[string][]dawg.WordForm
+	paradigmIDs map[string]uint16
+	tagIDs      map[string]uint16
+	suffixIDs   map[string]uint16
+	prefixIDs   map[string]uint16
+}
+
+func (d *Dictionary) newOverlay() *overlay {
+	o := &overlay{
+		d:           d,
+		paradigms:   slices.Clip(d.paradigms),
+		gramtab:     slices.Clip(d.gramtab),
+		suffixes:    slices.Clip(d.suffixes),
+		words:       make(map[string][]dawg.WordForm, len(d.userData)),
+		paradigmIDs: make(map[string]uint16, len(d.paradigms)),
+		tagIDs:      make(map[string]uint16, len(d.gramtab)),
+		suffixIDs:   make(map[string]uint16, len(d.suffixes)),
+		prefixIDs:   make(map[string]uint16, len(d.paradigmPrefixes)),
+	}
+	for w, forms := range d.userData {
+		o.words[w] = slices.Clone(forms)
+	}
+	for i, p := range d.paradigms {
+		o.paradigmIDs[paradigmKey(p)] = uint16(i)
+	}
+	for i := len(d.gramtab) - 1; i >= 0; i-- {
+		o.tagIDs[strings.Join(tag2grammemes(d.gramtab[i].String()), ",")] = uint16(i)
+	}
+	for i := len(d.suffixes) - 1; i >= 0; i-- {
+		o.suffixIDs[d.suffixes[i]] = uint16(i)
+	}
+	for i, p := range d.paradigmPrefixes {
+		o.prefixIDs[p] = uint16(i)
+	}
+	return o
+}
+
+// addForms adds a lexeme given by its forms.
+func (o *overlay) addForms(forms []WordForm) error {
+	if len(forms) == 0 {
+		return fmt.Errorf("lexeme has no forms")
+	}
+	stem, para := toParadigm(forms, o.prefixIDs)
+	paraArr := make([]uint16, len(para)*3)
+	for i, f := range para {
+		sid, ok := o.suffixIDs[f.Suffix]
+		if !ok {
+			if len(o.suffixes) > math.MaxUint16 {
+				return fmt.Errorf("too many suffixes")
+			}
+			sid = uint16(len(o.suffixes))
+			o.suffixes = append(o.suffixes, f.Suffix)
+			o.suffixIDs[f.Suffix] = sid
+		}
+		tid, err := o.tagID(f.Tag)
+		if err != nil {
+			return err
+		}
+		paraArr[i] = sid
+		paraArr[len(para)+i] = tid
+		paraArr[2*len(para)+i] = o.prefixIDs[f.Prefix]
+	}
+	key := paradigmKey(paraArr)
+	paraID, ok := o.paradigmIDs[key]
+	if !ok {
+		if len(o.paradigms) > math.MaxUint16 {
+			return fmt.Errorf("too many paradigms")
+		}
+		paraID = uint16(len(o.paradigms))
+		o.paradigmIDs[key] = paraID
+		o.paradigms = append(o.paradigms, paraArr)
+	}
+	for i, f := range para {
+		o.addWord(f.Prefix+stem+f.Suffix, paraID, i)
+	}
+	return nil
+}
+
+// tagID returns gramtab index of tag, adding the tag if needed. Tags are
+// matched by grammemes, so a known tag keeps its dictionary spelling.
+func (o *overlay) tagID(tag string) (uint16, error) {
+	tag = replaceRedundantGrammemes(strings.TrimSpace(tag))
+	key := strings.Join(tag2grammemes(tag), ",")
+	if id, ok := o.tagIDs[key]; ok {
+		return id, nil
+	}
+	t, err := tagset.New(tag)
+	if err != nil {
+		return 0, err
+	}
+	if len(o.gramtab) > math.MaxUint16 {
+		return 0, fmt.Errorf("too many tags")
+	}
+	id := uint16(len(o.gramtab))
+	o.gramtab = append(o.gramtab, *t)
+	o.tagIDs[key] = id
+	return id, nil
+}
+
+// addLike adds a lexeme sharing the paradigm of an existing word.
+func (o *overlay) addLike(e LexiconEntry) error {
+	if e.Lemma == "" {
+		return fmt.Errorf("lemma inflected like %q is empty", e.Like)
+	}
+	var required []string
+	if e.LikeTag != "" {
+		required = parseTagGrammemes(e.LikeTag)
+	}
+	forms := append(slices.Clone(o.words[e.Like]), o.d.words.Lookup(e.Like)...)
+	seen := map[uint16]bool{}
+	for _, wf := range forms {
+		if seen[wf.ParadigmID] {
+			continue
+		}
+		seen[wf.ParadigmID] = true
+		paradigm := o.paradigms[wf.ParadigmID]
+		n := len(paradigm) / 3
+		if !tagHasAll(&o.gramtab[paradigm[n]], required) {
+			continue
+		}
+		pref := o.d.paradigmPrefixes[paradigm[2*n]]
+		suff := o.suffixes[paradigm[0]]
+		if len(e.Lemma) < len(pref)+len(suff) || !strings.HasPrefix(e.Lemma, pref) || !strings.HasSuffix(e.Lemma, suff) {
+			continue
+		}
+		stem := e.Lemma[len(pref) : len(e.Lemma)-len(suff)]
+		for i := 0; i < n; i++ {
+			word := o.d.paradigmPrefixes[paradigm[2*n+i]] + stem + o.suffixes[paradigm[i]]
+			o.addWord(word, wf.ParadigmID, i)
+		}
+		return nil
+	}
+	if len(forms) == 0 {
+		return fmt.Errorf("word %q is unknown", e.Like)
+	}
+	return fmt.Errorf("no paradigm of %q fits %q", e.Like, e.Lemma)
+}
+
+func (o *overlay) addWord(word string, paraID uint16, idx int) {
+	wf := dawg.WordForm{ParadigmID: paraID, FormIndex: uint16(idx)}
+	if !slices.Contains(o.words[word], wf) {
+		o.words[word] = append(o.words[word], wf)
+	}
+}
+
+func parseTagGrammemes(tag string) []string {
+	return strings.FieldsFunc(tag, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+func tagHasAll(t *tagset.Tag, grams []string) bool {
+	for _, g := range grams {
+		if ok, _ := t.Contains(g); !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package dict
+
+import (
+	"strings"
+	"testing"
+)
+
+const testLexiconTSV = `# user lexicon
+кот	NOUN,anim,masc sing,nomn
+кота	NOUN,anim,masc sing,gent
+
+крот	=кот
+`
+
+func TestAddLexicon(t *testing.T) {
+	d := openTestDict(t, saveTestDict(t, compileTestDict(t, nil, nil)))
+	lex, err := ReadLexiconTSV(strings.NewReader(testLexiconTSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lex.Entries) != 2 || lex.Entries[1].Like != "кот" {
+		t.Fatalf("unexpected entries: %+v", lex.Entries)
+	}
+	paradigms := len(d.paradigms)
+	if err := d.AddLexicon(lex); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.paradigms) != paradigms+1 {
+		t.Errorf("paradigms = %d, want %d", len(d.paradigms), paradigms+1)
+	}
+	for _, w := range []string{"кот", "кота", "крот", "крота", "ёж"} {
+		if !d.WordIsKnown(w, nil) {
+			t.Errorf("%q is unknown", w)
+		}
+	}
+	items := d.SimilarItems("крота", nil)
+	if len(items) != 1 || len(items[0].Forms) != 1 {
+		t.Fatalf("SimilarItems(крота) = %v", items)
+	}
+	wf := items[0].Forms[0]
+	if got := d.BuildNormalForm(int(wf.ParadigmID), int(wf.FormIndex), "крота"); got != "крот" {
+		t.Errorf("normal form = %q, want крот", got)
+	}
+	if got := d.BuildTagInfo(int(wf.ParadigmID), int(wf.FormIndex)); got.String() != "NOUN,anim,masc sing,gent" {
+		t.Errorf("tag = %q", got.String())
+	}
+
+	bad, err := ReadLexiconXML(strings.NewReader(`<lemmata><lemma like="ёж"><l t="уж"/></lemma><lemma like="нет"><l t="да"/></lemma></lemmata>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddLexicon(bad); err == nil {
+		t.Errorf("expected error for a paradigm which does not fit")
+	}
+	if d.WordIsKnown("уж", nil) {
+		t.Errorf("failed AddLexicon changed the dictionary")
+	}
+}
@@ -0,0 +1,76 @@
+package dict
+
+import "fmt"
+
+// SkippedLinkTypes lists OpenCorpora link types whose lexemes are not joined
+// during compilation. Like pymorphy2, all other types are merged: NAME-PATR
+// (7), FULL-CONTRACTED (21), CARDINAL-ORDINAL (23) and
+// ADJF_TEXT-ADJF_NUMBER (27) are skipped. The set can be overridden with the
+// "skip_link_types" compile option.
+var SkippedLinkTypes = []string{"7", "21", "23", "27"}
+
+// stringsOption reads a list of strings option by name. Values decoded from
+// JSON are []any.
+func stringsOption(opts map[string]any, name string, def []string) []string {
+	switch v := opts[name].(type) {
+	case []string:
+		return v
+	case []any:
+		res := make([]string, 0, len(v))
+		for _, it := range v {
+			res = append(res, fmt.Sprint(it))
+		}
+		return res
+	}
+	return def
+}
+
+// JoinLexemes combines linked lexemes into a single lexeme. For every link,
+// unless its type is one of skipTypes, the lexeme at the link end is appended
+// to the lexeme at the link start. The source map is not modified; the result contains only
+// non-empty lexemes.
+func JoinLexemes(lexemes map[string][]WordForm, links []Link, skipTypes []string) map[string][]WordForm {
+	skipped := make(map[string]struct{}, len(skipTypes))
+	for _, t := range skipTypes {
+		skipped[t] = struct{}{}
+	}
+	res := make(map[string][]WordForm, len(lexemes))
+	for id, forms := range lexemes {
+		res[id] = append([]WordForm(nil), forms...)
+	}
+
+	moves := map[string]string{}
+	for _, ln := range links {
+		if _, ok := skipped[ln.Type]; ok {
+			continue
+		}
+		from, to := ln.To, ln.From
+		forms, ok := res[from]
+		if !ok {
+			continue
+		}
+		for {
+			next, ok := moves[to]
+			if !ok {
+				break
+			}
+			to = next
+		}
+		if to == from {
+			continue
+		}
+		if _, ok := res[to]; !ok {
+			continue
+		}
+		res[to] = append(res[to], forms...)
+		res[from] = nil
+		moves[from] = to
+	}
+
+	for id, forms := range res {
+		if len(forms) == 0 {
+			delete(res, id)
+		}
+	}
+	return res
+}
@@ -0,0 +1,46 @@
+package dict
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJoinLexemes(t *testing.T) {
+	lexemes := map[string][]WordForm{
+		"1": {{Word: "прочитать", Tag: "INFN,perf,tran"}},
+		"2": {{Word: "прочитанный", Tag: "PRTF,perf,tran,past,pssv masc,sing,nomn"}},
+		"3": {{Word: "прочитан", Tag: "PRTS,perf,past,pssv masc,sing"}},
+		"4": {{Word: "иван", Tag: "NOUN,anim,masc,Name sing,nomn"}},
+		"5": {{Word: "иванович", Tag: "NOUN,anim,masc,Patr sing,nomn"}},
+		"6": {{Word: "читать", Tag: "INFN,impf,tran"}},
+		"7": {{Word: "читающий", Tag: "PRTF,impf,tran,pres,actv masc,sing,nomn"}},
+	}
+	links := []Link{
+		// INFN-PRTF and PRTF-PRTS: "прочитан" joins "прочитать" via "прочитанный"
+		{From: "1", To: "2", Type: "4"},
+		{From: "2", To: "3", Type: "6"},
+		// NAME-PATR is not merged
+		{From: "4", To: "5", Type: "7"},
+		// types unknown to the compiler are merged
+		{From: "6", To: "7", Type: "30"},
+	}
+	got := JoinLexemes(lexemes, links, SkippedLinkTypes)
+	want := map[string][]string{
+		"1": {"прочитать", "прочитанный", "прочитан"},
+		"4": {"иван"},
+		"5": {"иванович"},
+		"6": {"читать", "читающий"},
+	}
+	words := map[string][]string{}
+	for id, forms := range got {
+		for _, f := range forms {
+			words[id] = append(words[id], f.Word)
+		}
+	}
+	if !reflect.DeepEqual(words, want) {
+		t.Errorf("JoinLexemes = %v, want %v", words, want)
+	}
+	if len(lexemes["1"]) != 1 || len(lexemes["2"]) != 1 {
+		t.Errorf("JoinLexemes modified its input: %v", lexemes)
+	}
+}
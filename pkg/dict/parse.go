@@ -9,11 +9,12 @@ import (
 
 // ParsedDictionary holds raw information extracted from OpenCorpora XML.
 type ParsedDictionary struct {
-	Lexemes   map[string][]WordForm
-	Links     []Link
-	Grammemes []Grammeme
-	Version   string
-	Revision  string
+	Lexemes      map[string][]WordForm
+	Links        []Link
+	Grammemes    []Grammeme
+	Restrictions []Restriction
+	Version      string
+	Revision     string
 }
 
 // WordForm represents word with its tag.
@@ -37,119 +38,213 @@ type Grammeme struct {
 	Description string
 }
 
-// getDictionaryInfo returns version and revision from XML file.
-func getDictionaryInfo(filename string) (string, string, error) {
-	f, err := os.Open(filename)
-	if err != nil {
-		return "", "", err
+// Restriction represents grammeme compatibility rule.
+type Restriction struct {
+	Type      string
+	Auto      string
+	LeftType  string
+	Left      string
+	RightType string
+	Right     string
+}
+
+// OpencorporaHandler receives dictionary entries while XML is streamed.
+// Nil callbacks are skipped; an error returned by a callback stops parsing.
+type OpencorporaHandler struct {
+	Info        func(version, revision string) error
+	Grammeme    func(g Grammeme) error
+	Restriction func(r Restriction) error
+	Lemma       func(id string, forms []WordForm) error
+	Link        func(l Link) error
+}
+
+type xmlGrammeme struct {
+	Name        string `xml:"name"`
+	Parent      string `xml:"parent,attr"`
+	Alias       string `xml:"alias"`
+	Description string `xml:"description"`
+}
+
+type xmlGrams struct {
+	Gs []struct {
+		V string `xml:"v,attr"`
+	} `xml:"g"`
+}
+
+type xmlLemma struct {
+	ID string   `xml:"id,attr"`
+	L  xmlGrams `xml:"l"`
+	Fs []struct {
+		T string `xml:"t,attr"`
+		xmlGrams
+	} `xml:"f"`
+}
+
+type xmlRestriction struct {
+	Type  string             `xml:"type,attr"`
+	Auto  string             `xml:"auto,attr"`
+	Left  xmlRestrictionSide `xml:"left"`
+	Right xmlRestrictionSide `xml:"right"`
+}
+
+type xmlRestrictionSide struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlLink struct {
+	From string `xml:"from,attr"`
+	To   string `xml:"to,attr"`
+	Type string `xml:"type,attr"`
+}
+
+func attr(attrs []xml.Attr, name string) string {
+	for _, a := range attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
 	}
-	defer f.Close()
-	dec := xml.NewDecoder(f)
+	return ""
+}
+
+// StreamOpencorporaXML reads OpenCorpora XML dictionary from r in a single
+// pass and passes every grammeme, restriction, lemma and link to h. Only one
+// element is kept in memory at a time.
+func StreamOpencorporaXML(r io.Reader, h OpencorporaHandler) error {
+	dec := xml.NewDecoder(r)
 	for {
 		tok, err := dec.Token()
 		if err == io.EOF {
-			break
+			return nil
 		}
 		if err != nil {
-			return "", "", err
+			return err
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
 		}
-		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "dictionary" {
-			ver := attr(se.Attr, "version")
-			rev := attr(se.Attr, "revision")
-			return ver, rev, nil
+		switch se.Name.Local {
+		case "dictionary":
+			if h.Info != nil {
+				if err := h.Info(attr(se.Attr, "version"), attr(se.Attr, "revision")); err != nil {
+					return err
+				}
+			}
+		case "grammeme":
+			var g xmlGrammeme
+			if err := dec.DecodeElement(&g, &se); err != nil {
+				return err
+			}
+			if h.Grammeme != nil {
+				if err := h.Grammeme(Grammeme(g)); err != nil {
+					return err
+				}
+			}
+		case "restr":
+			var rs xmlRestriction
+			if err := dec.DecodeElement(&rs, &se); err != nil {
+				return err
+			}
+			if h.Restriction != nil {
+				err := h.Restriction(Restriction{
+					Type:      rs.Type,
+					Auto:      rs.Auto,
+					LeftType:  rs.Left.Type,
+					Left:      strings.TrimSpace(rs.Left.Value),
+					RightType: rs.Right.Type,
+					Right:     strings.TrimSpace(rs.Right.Value),
+				})
+				if err != nil {
+					return err
+				}
+			}
+		case "lemma":
+			var l xmlLemma
+			if err := dec.DecodeElement(&l, &se); err != nil {
+				return err
+			}
+			if h.Lemma != nil {
+				if err := h.Lemma(l.ID, lemmaForms(l)); err != nil {
+					return err
+				}
+			}
+		case "link":
+			var ln xmlLink
+			if err := dec.DecodeElement(&ln, &se); err != nil {
+				return err
+			}
+			if h.Link != nil {
+				if err := h.Link(Link(ln)); err != nil {
+					return err
+				}
+			}
 		}
 	}
-	return "", "", nil
 }
 
-func attr(attrs []xml.Attr, name string) string {
-	for _, a := range attrs {
-		if a.Name.Local == name {
-			return a.Value
+// lemmaForms returns (word, tag) pairs of a lemma. A lemma having a form
+// without any grammatical information is dropped entirely.
+func lemmaForms(l xmlLemma) []WordForm {
+	base := l.L.join()
+	forms := make([]WordForm, 0, len(l.Fs))
+	for _, f := range l.Fs {
+		gram := f.join()
+		if base == "" && gram == "" {
+			return []WordForm{}
 		}
+		tag := strings.TrimSpace(base + " " + gram)
+		forms = append(forms, WordForm{Word: strings.ToLower(f.T), Tag: tag})
 	}
-	return ""
+	return forms
 }
 
 // ParseOpencorporaXML parses XML dictionary file.
 func ParseOpencorporaXML(filename string) (*ParsedDictionary, error) {
-	data := &ParsedDictionary{
-		Lexemes:   map[string][]WordForm{},
-		Links:     []Link{},
-		Grammemes: []Grammeme{},
-	}
-	ver, rev, _ := getDictionaryInfo(filename)
-	data.Version = ver
-	data.Revision = rev
-
-	content, err := os.ReadFile(filename)
+	f, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
-	var xdict struct {
-		XMLName   xml.Name `xml:"dictionary"`
-		Grammemes []struct {
-			Name        string `xml:"name"`
-			Parent      string `xml:"parent,attr"`
-			Alias       string `xml:"alias"`
-			Description string `xml:"description"`
-		} `xml:"grammeme"`
-		Lemmata []struct {
-			ID string `xml:"id,attr"`
-			L  struct {
-				Gs []struct {
-					V string `xml:"v,attr"`
-				} `xml:"g"`
-			} `xml:"l"`
-			Fs []struct {
-				T  string `xml:"t,attr"`
-				Gs []struct {
-					V string `xml:"v,attr"`
-				} `xml:"g"`
-			} `xml:"f"`
-		} `xml:"lemma"`
-		Links []struct {
-			From string `xml:"from,attr"`
-			To   string `xml:"to,attr"`
-			Type string `xml:"type,attr"`
-		} `xml:"link"`
-	}
-	if err := xml.Unmarshal(content, &xdict); err != nil {
-		return nil, err
-	}
-
-	for _, g := range xdict.Grammemes {
-		data.Grammemes = append(data.Grammemes, Grammeme{
-			Name:        g.Name,
-			Parent:      g.Parent,
-			Alias:       g.Alias,
-			Description: g.Description,
-		})
-	}
+	defer f.Close()
 
-	for _, l := range xdict.Lemmata {
-		base := joinGrams(l.L.Gs)
-		forms := []WordForm{}
-		for _, f := range l.Fs {
-			gram := joinGrams(f.Gs)
-			tag := strings.TrimSpace(base + " " + gram)
-			forms = append(forms, WordForm{Word: strings.ToLower(f.T), Tag: tag})
-		}
-		data.Lexemes[l.ID] = forms
+	data := &ParsedDictionary{
+		Lexemes:      map[string][]WordForm{},
+		Links:        []Link{},
+		Grammemes:    []Grammeme{},
+		Restrictions: []Restriction{},
 	}
-
-	for _, ln := range xdict.Links {
-		data.Links = append(data.Links, Link{From: ln.From, To: ln.To, Type: ln.Type})
+	err = StreamOpencorporaXML(f, OpencorporaHandler{
+		Info: func(version, revision string) error {
+			data.Version = version
+			data.Revision = revision
+			return nil
+		},
+		Grammeme: func(g Grammeme) error {
+			data.Grammemes = append(data.Grammemes, g)
+			return nil
+		},
+		Restriction: func(r Restriction) error {
+			data.Restrictions = append(data.Restrictions, r)
+			return nil
+		},
+		Lemma: func(id string, forms []WordForm) error {
+			data.Lexemes[id] = forms
+			return nil
+		},
+		Link: func(l Link) error {
+			data.Links = append(data.Links, l)
+			return nil
+		},
+	})
+	if err != nil {
+		return nil, err
 	}
-
 	return data, nil
 }
 
-func joinGrams(gs []struct {
-	V string `xml:"v,attr"`
-}) string {
-	parts := make([]string, 0, len(gs))
-	for _, g := range gs {
+func (x xmlGrams) join() string {
+	parts := make([]string, 0, len(x.Gs))
+	for _, g := range x.Gs {
 		parts = append(parts, g.V)
 	}
 	return strings.Join(parts, ",")
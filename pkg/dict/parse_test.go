@@ -0,0 +1,89 @@
+package dict
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStreamOpencorporaXML(t *testing.T) {
+	var lemmaIDs []string
+	var version, revision string
+	var grammemes []Grammeme
+	var restrictions []Restriction
+	var links []Link
+	err := StreamOpencorporaXML(strings.NewReader(testDictXML), OpencorporaHandler{
+		Info: func(v, r string) error {
+			version, revision = v, r
+			return nil
+		},
+		Grammeme: func(g Grammeme) error {
+			grammemes = append(grammemes, g)
+			return nil
+		},
+		Restriction: func(r Restriction) error {
+			restrictions = append(restrictions, r)
+			return nil
+		},
+		Lemma: func(id string, forms []WordForm) error {
+			lemmaIDs = append(lemmaIDs, id)
+			if id == "1" && (len(forms) != 2 || forms[1].Word != "ежа" || forms[1].Tag != "NOUN,anim sing,gent") {
+				t.Errorf("unexpected forms: %v", forms)
+			}
+			return nil
+		},
+		Link: func(l Link) error {
+			links = append(links, l)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != "0.92" || revision != "389440" {
+		t.Errorf("unexpected version info: %s %s", version, revision)
+	}
+	if len(grammemes) != 2 || grammemes[1] != (Grammeme{Name: "anim", Parent: "NOUN", Alias: "од", Description: "одушевлённое"}) {
+		t.Errorf("unexpected grammemes: %v", grammemes)
+	}
+	if len(restrictions) != 1 || restrictions[0].Left != "NOUN" || restrictions[0].Right != "anim" {
+		t.Errorf("unexpected restrictions: %v", restrictions)
+	}
+	if strings.Join(lemmaIDs, ",") != "1,2" {
+		t.Errorf("unexpected lemmas: %v", lemmaIDs)
+	}
+	if len(links) != 1 || links[0] != (Link{From: "1", To: "2", Type: "10"}) {
+		t.Errorf("unexpected links: %v", links)
+	}
+}
+
+const testCorpusXML = `<?xml version="1.0" encoding="utf-8"?>
+<annotation version="0.12" revision="4201">
+<text id="1" parent="0" name="test"><paragraphs><paragraph id="1"><sentence id="1">
+<source>Ежи, ежи</source>
+<tokens>
+<token id="1" text="Ежи"><tfr rev_id="1" t="Ежи"><v><l id="2" t="ежи"><g v="NOUN"/><g v="anim"/><g v="plur"/><g v="loc1"/></l></v></tfr></token>
+<token id="2" text=","><tfr rev_id="2" t=","><v><l id="0" t=","><g v="PNCT"/></l></v></tfr></token>
+<token id="3" text="ежи"><tfr rev_id="3" t="ежи"><v><l id="2" t="ежи"><g v="NOUN"/></l></v><v><l id="1" t="ёж"><g v="NOUN"/></l></v></tfr></token>
+</tokens>
+</sentence></paragraph></paragraphs></text>
+</annotation>`
+
+func TestStreamOpencorporaCorpus(t *testing.T) {
+	var tokens []CorpusToken
+	err := StreamOpencorporaCorpus(strings.NewReader(testCorpusXML), func(tok CorpusToken) error {
+		tokens = append(tokens, tok)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 3 || len(tokens[2].Parses) != 2 || tokens[2].Parses[1].Lemma != "ёж" {
+		t.Fatalf("unexpected tokens: %v", tokens)
+	}
+	if tokens[0].Text != "Ежи" || tokens[0].Parses[0].Tag != "NOUN,anim,plur,loc1" {
+		t.Errorf("unexpected token: %v", tokens[0])
+	}
+	if got := strings.Join(TagGrammemes(tokens[0].Parses[0].Tag), ","); got != "NOUN,anim,loct,plur" {
+		t.Errorf("TagGrammemes = %s", got)
+	}
+}
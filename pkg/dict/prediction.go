@@ -0,0 +1,212 @@
+package dict
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"morphy/pkg/dawg"
+	"morphy/pkg/utils"
+)
+
+// Default values of compile options affecting suffix prediction.
+const (
+	DefaultMinEndingFreq         = 2
+	DefaultMinParadigmPopularity = 3
+	DefaultMaxSuffixLength       = 5
+)
+
+// DefaultParadigmPrefixes is used when "paradigm_prefixes" compile option is
+// not provided.
+var DefaultParadigmPrefixes = []string{""}
+
+// languageParadigmPrefixes maps language codes to paradigm prefixes of their
+// dictionaries. Ukrainian prefixes form superlatives, e.g. "найкращий",
+// "якнайкращий".
+var languageParadigmPrefixes = map[string][]string{
+	"ru": {"", "по", "наи"},
+	"uk": {"", "най", "якнай", "щонай"},
+}
+
+// LanguageParadigmPrefixes returns paradigm prefixes of language code or
+// DefaultParadigmPrefixes for other languages.
+func LanguageParadigmPrefixes(code string) []string {
+	if prefixes, ok := languageParadigmPrefixes[code]; ok {
+		return slices.Clone(prefixes)
+	}
+	return DefaultParadigmPrefixes
+}
+
+// LanguageCompileOptions returns a copy of opts in which a missing
+// "paradigm_prefixes" option is set to the prefixes of language code.
+func LanguageCompileOptions(code string, opts map[string]any) map[string]any {
+	res := maps.Clone(opts)
+	if res == nil {
+		res = map[string]any{}
+	}
+	if _, ok := res["paradigm_prefixes"]; !ok {
+		res["paradigm_prefixes"] = LanguageParadigmPrefixes(code)
+	}
+	return res
+}
+
+// compileOptionsWithDefaults returns a copy of opts with missing options
+// filled with default values.
+func compileOptionsWithDefaults(opts map[string]any) map[string]any {
+	res := map[string]any{
+		"paradigm_prefixes":       DefaultParadigmPrefixes,
+		"min_ending_freq":         DefaultMinEndingFreq,
+		"min_paradigm_popularity": DefaultMinParadigmPopularity,
+		"max_suffix_length":       DefaultMaxSuffixLength,
+	}
+	for k, v := range opts {
+		res[k] = v
+	}
+	return res
+}
+
+// intOption reads integer option by name. Values decoded from JSON are
+// float64, values passed by callers are usually int.
+func intOption(opts map[string]any, name string, def int) int {
+	switch v := opts[name].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case uint16:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return def
+}
+
+// boolOption reads boolean option by name; missing options are false.
+func boolOption(opts map[string]any, name string) bool {
+	v, _ := opts[name].(bool)
+	return v
+}
+
+// wordEntry is a single (word, paradigm, form) record produced by compilation.
+type wordEntry struct {
+	Word       string
+	ParadigmID uint16
+	FormIndex  uint16
+}
+
+// formKey identifies a form inside a paradigm.
+type formKey struct {
+	ParadigmID uint16
+	FormIndex  uint16
+}
+
+// suffixesPredictionData builds data for prediction suffixes DAWGs, one map
+// per paradigm prefix. It mirrors pymorphy2 “_suffixes_prediction_data“.
+func suffixesPredictionData(words []wordEntry, paradigmPopularity map[uint16]int, gramtab []string, paradigms [][]uint16, suffixes []string, paradigmPrefixes []string, minEndingFreq, minParadigmPopularity, maxSuffixLength int) []map[string][]dawg.Prediction {
+	// ending => number of occurrences; used for removing rare endings
+	endingCounts := map[string]int{}
+	// [prefixID][ending][POS][form] => number of occurrences
+	prefixEndings := make([]map[string]map[string]map[formKey]int, len(paradigmPrefixes))
+	for i := range prefixEndings {
+		prefixEndings[i] = map[string]map[string]map[formKey]int{}
+	}
+
+	for _, w := range words {
+		if paradigmPopularity[w.ParadigmID] < minParadigmPopularity {
+			continue
+		}
+		paradigm := paradigms[w.ParadigmID]
+		n := len(paradigm) / 3
+		idx := int(w.FormIndex)
+		tag := gramtab[paradigm[n+idx]]
+		prefixID := paradigm[2*n+idx]
+		formPrefix := paradigmPrefixes[prefixID]
+		formSuffix := suffixes[paradigm[idx]]
+
+		// pseudo-paradigms are useless for prediction
+		if len(w.Word) == len(formPrefix)+len(formSuffix) {
+			continue
+		}
+
+		pos := strings.Split(strings.Replace(tag, " ", ",", 1), ",")[0]
+		runes := []rune(w.Word)
+		start := len([]rune(formSuffix))
+		if start < 1 {
+			start = 1
+		}
+		for i := start; i <= maxSuffixLength; i++ {
+			from := len(runes) - i
+			if from < 0 {
+				from = 0
+			}
+			end := string(runes[from:])
+			endingCounts[end]++
+			byPOS, ok := prefixEndings[prefixID][end]
+			if !ok {
+				byPOS = map[string]map[formKey]int{}
+				prefixEndings[prefixID][end] = byPOS
+			}
+			forms, ok := byPOS[pos]
+			if !ok {
+				forms = map[formKey]int{}
+				byPOS[pos] = forms
+			}
+			forms[formKey{ParadigmID: w.ParadigmID, FormIndex: w.FormIndex}]++
+		}
+	}
+
+	res := make([]map[string][]dawg.Prediction, len(prefixEndings))
+	for i, endings := range prefixEndings {
+		res[i] = suffixesDawgData(endings, endingCounts, minEndingFreq)
+	}
+	return res
+}
+
+// suffixesDawgData selects the most popular parses for each ending and POS.
+func suffixesDawgData(endings map[string]map[string]map[formKey]int, endingCounts map[string]int, minEndingFreq int) map[string][]dawg.Prediction {
+	type formCount struct {
+		form formKey
+		cnt  int
+	}
+	res := map[string][]dawg.Prediction{}
+	for ending, byPOS := range endings {
+		if endingCounts[ending] < minEndingFreq {
+			continue
+		}
+		for _, forms := range byPOS {
+			items := make([]formCount, 0, len(forms))
+			for f, c := range forms {
+				items = append(items, formCount{form: f, cnt: c})
+			}
+			common := utils.LargestElements(items, func(fc formCount) float64 { return float64(fc.cnt) }, 1)
+			for _, fc := range common {
+				cnt := fc.cnt
+				if cnt > math.MaxUint16 {
+					cnt = math.MaxUint16
+				}
+				res[ending] = append(res[ending], dawg.Prediction{
+					Count:      uint16(cnt),
+					ParadigmID: fc.form.ParadigmID,
+					FormIndex:  fc.form.FormIndex,
+				})
+			}
+		}
+	}
+	// Keep a deterministic order of values; RecordDAWG files (see
+	// SavePymorphy2Dict) reorder them by their base64-encoded records.
+	for _, preds := range res {
+		sort.Slice(preds, func(i, j int) bool {
+			a, b := preds[i], preds[j]
+			if a.Count != b.Count {
+				return a.Count < b.Count
+			}
+			if a.ParadigmID != b.ParadigmID {
+				return a.ParadigmID < b.ParadigmID
+			}
+			return a.FormIndex < b.FormIndex
+		})
+	}
+	return res
+}
@@ -0,0 +1,87 @@
+package dict
+
+import (
+	"reflect"
+	"testing"
+
+	"morphy/pkg/dawg"
+)
+
+func TestSuffixesPredictionData(t *testing.T) {
+	suffixes := []string{"", "а", "ы", "ой"}
+	gramtab := []string{"NOUN,femn sing,nomn", "NOUN,femn sing,gent", "VERB impr"}
+	paradigms := [][]uint16{
+		// -а, -ы
+		{1, 2, 0, 1, 0, 0},
+		// -ой
+		{3, 2, 0},
+	}
+	popularity := map[uint16]int{0: 3, 1: 1}
+	words := []wordEntry{
+		{"мама", 0, 0}, {"мамы", 0, 1},
+		{"рама", 0, 0}, {"рамы", 0, 1},
+		{"лампа", 0, 0},
+		{"пой", 1, 0},
+	}
+	tests := []struct {
+		name                                          string
+		minEndingFreq, minPopularity, maxSuffixLength int
+		want                                          map[string][]dawg.Prediction
+	}{
+		{
+			name: "defaults", minEndingFreq: 2, minPopularity: 2, maxSuffixLength: 2,
+			want: map[string][]dawg.Prediction{
+				"а":  {{Count: 3, ParadigmID: 0, FormIndex: 0}},
+				"ма": {{Count: 2, ParadigmID: 0, FormIndex: 0}},
+				"ы":  {{Count: 2, ParadigmID: 0, FormIndex: 1}},
+				"мы": {{Count: 2, ParadigmID: 0, FormIndex: 1}},
+			},
+		},
+		{
+			name: "no filters", minEndingFreq: 1, minPopularity: 1, maxSuffixLength: 2,
+			want: map[string][]dawg.Prediction{
+				"а":  {{Count: 3, ParadigmID: 0, FormIndex: 0}},
+				"ма": {{Count: 2, ParadigmID: 0, FormIndex: 0}},
+				"па": {{Count: 1, ParadigmID: 0, FormIndex: 0}},
+				"ы":  {{Count: 2, ParadigmID: 0, FormIndex: 1}},
+				"мы": {{Count: 2, ParadigmID: 0, FormIndex: 1}},
+				// endings are not shorter than the paradigm suffix
+				"ой": {{Count: 1, ParadigmID: 1, FormIndex: 0}},
+			},
+		},
+		{
+			name: "short suffixes", minEndingFreq: 2, minPopularity: 2, maxSuffixLength: 1,
+			want: map[string][]dawg.Prediction{
+				"а": {{Count: 3, ParadigmID: 0, FormIndex: 0}},
+				"ы": {{Count: 2, ParadigmID: 0, FormIndex: 1}},
+			},
+		},
+		{
+			name: "rare endings", minEndingFreq: 4, minPopularity: 1, maxSuffixLength: 3,
+			want: map[string][]dawg.Prediction{},
+		},
+	}
+	for _, tt := range tests {
+		got := suffixesPredictionData(words, popularity, gramtab, paradigms, suffixes, []string{""},
+			tt.minEndingFreq, tt.minPopularity, tt.maxSuffixLength)
+		if len(got) != 1 || !reflect.DeepEqual(got[0], tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLanguageCompileOptions(t *testing.T) {
+	for code, want := range map[string][]string{
+		"ru": {"", "по", "наи"},
+		"uk": {"", "най", "якнай", "щонай"},
+		"xx": DefaultParadigmPrefixes,
+	} {
+		if got := LanguageCompileOptions(code, nil)["paradigm_prefixes"]; !reflect.DeepEqual(got, want) {
+			t.Errorf("paradigm_prefixes of %q = %q, want %q", code, got, want)
+		}
+	}
+	opts := LanguageCompileOptions("ru", map[string]any{"paradigm_prefixes": []string{""}})
+	if got := opts["paradigm_prefixes"]; !reflect.DeepEqual(got, []string{""}) {
+		t.Errorf("explicit paradigm_prefixes replaced with %q", got)
+	}
+}
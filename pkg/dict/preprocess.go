@@ -3,22 +3,31 @@ package dict
 import (
 	"sort"
 	"strings"
+
+	"morphy/pkg/utils"
 )
 
-// SimplifyTags normalizes tag strings and removes duplicates.
+// SimplifyTags normalizes tag strings and removes duplicates. Lexemes are
+// processed on a pool of GOMAXPROCS workers.
 func SimplifyTags(pd *ParsedDictionary, skipSpaceAmbiguity bool) {
-	spellings := getTagSpellings(pd)
+	ids := sortedLexemeIDs(pd.Lexemes)
+	spellings := getTagSpellings(pd, ids)
 	replaces := getDuplicateTagReplaces(spellings, skipSpaceAmbiguity)
-	for lexID, forms := range pd.Lexemes {
+	res := make([][]WordForm, len(ids))
+	utils.ParallelFor(len(ids), 0, func(i int) {
+		forms := pd.Lexemes[ids[i]]
 		nf := make([]WordForm, len(forms))
-		for i, wf := range forms {
+		for j, wf := range forms {
 			tag := replaceRedundantGrammemes(wf.Tag)
 			if r, ok := replaces[tag]; ok {
 				tag = r
 			}
-			nf[i] = WordForm{Word: wf.Word, Tag: tag}
+			nf[j] = WordForm{Word: wf.Word, Tag: tag}
 		}
-		pd.Lexemes[lexID] = nf
+		res[i] = nf
+	})
+	for i, id := range ids {
+		pd.Lexemes[id] = res[i]
 	}
 }
 
@@ -53,15 +62,25 @@ func replaceRedundantGrammemes(tag string) string {
 	return tag
 }
 
-func getTagSpellings(pd *ParsedDictionary) map[string]map[string]int {
+// getTagSpellings counts spellings of tags by their sorted grammemes.
+// Grammemes are computed in parallel and counted in the order of ids.
+func getTagSpellings(pd *ParsedDictionary, ids []string) map[string]map[string]int {
+	grams := make([][]string, len(ids))
+	utils.ParallelFor(len(ids), 0, func(i int) {
+		forms := pd.Lexemes[ids[i]]
+		grams[i] = make([]string, len(forms))
+		for j, wf := range forms {
+			grams[i][j] = strings.Join(tag2grammemes(wf.Tag), ",")
+		}
+	})
 	res := map[string]map[string]int{}
-	for _, forms := range pd.Lexemes {
-		for _, wf := range forms {
-			grams := strings.Join(tag2grammemes(wf.Tag), ",")
-			if _, ok := res[grams]; !ok {
-				res[grams] = map[string]int{}
+	for i, id := range ids {
+		for j, wf := range pd.Lexemes[id] {
+			g := grams[i][j]
+			if _, ok := res[g]; !ok {
+				res[g] = map[string]int{}
 			}
-			res[grams][wf.Tag]++
+			res[g][wf.Tag]++
 		}
 	}
 	return res
@@ -79,7 +98,14 @@ func getDuplicateTagReplaces(spellings map[string]map[string]int, skipSpaceAmbig
 			for t, c := range tags {
 				items = append(items, kv{t, c})
 			}
-			sort.Slice(items, func(i, j int) bool { return items[i].cnt > items[j].cnt })
+			// ties are broken by spelling, so the result does not depend
+			// on map iteration order
+			sort.Slice(items, func(i, j int) bool {
+				if items[i].cnt != items[j].cnt {
+					return items[i].cnt > items[j].cnt
+				}
+				return items[i].tag < items[j].tag
+			})
 			top := items[0].tag
 			for _, it := range items[1:] {
 				replaces[it.tag] = top
@@ -0,0 +1,283 @@
+package dict
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"morphy/pkg/dawg"
+	"morphy/pkg/tagset"
+)
+
+// Pymorphy2WordsFile marks dictionaries in the native pymorphy2 layout
+// (words.dawg, paradigms.array, prediction-suffixes-N.dawg), e.g. unpacked
+// pymorphy2-dicts-ru packages.
+const Pymorphy2WordsFile = "words.dawg"
+
+// Pymorphy2FormatVersion is the pymorphy2 dictionary format written by
+// SavePymorphy2Dict.
+const Pymorphy2FormatVersion = "2.4"
+
+// pymorphy2GramtabFormat is the gramtab format used for internal tags.
+const pymorphy2GramtabFormat = "opencorpora-int"
+
+// pymorphy2CyrGramtabFormat is the gramtab format with grammeme aliases.
+const pymorphy2CyrGramtabFormat = "opencorpora-ext"
+
+// loadPymorphy2Dict reads dictionary data stored in the pymorphy2 layout.
+func loadPymorphy2Dict(path string, ld *LoadedDictionary) error {
+	f := func(name string) string { return filepath.Join(path, name) }
+
+	gramtabFile := "gramtab-" + pymorphy2GramtabFormat + ".json"
+	if formats, ok := ld.Meta["gramtab_formats"].(map[string]any); ok {
+		if name, ok := formats[pymorphy2GramtabFormat].(string); ok {
+			gramtabFile = name
+		}
+	}
+	var gramtabStr []string
+	if err := jsonRead(f(gramtabFile), &gramtabStr); err != nil {
+		return err
+	}
+	ld.Gramtab = make([]tagset.Tag, 0, len(gramtabStr))
+	for _, t := range gramtabStr {
+		tg, err := tagset.New(t)
+		if err != nil {
+			return err
+		}
+		ld.Gramtab = append(ld.Gramtab, *tg)
+	}
+
+	if err := jsonRead(f("suffixes.json"), &ld.Suffixes); err != nil {
+		return err
+	}
+	paradigms, err := readParadigmsArray(f("paradigms.array"))
+	if err != nil {
+		return err
+	}
+	ld.Paradigms = paradigms
+	if ld.Words, err = dawg.LoadWordsDawg(f(Pymorphy2WordsFile)); err != nil {
+		return err
+	}
+
+	if opts, ok := ld.Meta["compile_options"].(map[string]any); ok && opts["paradigm_prefixes"] != nil {
+		ld.ParadigmPrefixes = stringsOption(opts, "paradigm_prefixes", nil)
+	} else if err := jsonRead(f("paradigm-prefixes.json"), &ld.ParadigmPrefixes); err != nil {
+		return err
+	}
+
+	ld.PredictionSuffixes = make([]*dawg.PredictionSuffixesDAWG, 0, len(ld.ParadigmPrefixes))
+	for i := range ld.ParadigmPrefixes {
+		pd, err := dawg.LoadPredictionSuffixesDAWG(f(fmt.Sprintf("prediction-suffixes-%d.dawg", i)))
+		if err != nil {
+			return err
+		}
+		ld.PredictionSuffixes = append(ld.PredictionSuffixes, pd)
+	}
+	return nil
+}
+
+// SavePymorphy2Dict saves compiled dictionary to outPath in the native
+// pymorphy2 layout, so the directory can be loaded both by LoadDict and by
+// pymorphy2.MorphAnalyzer(path=...). Dictionary files already in outPath
+// are removed first.
+func SavePymorphy2Dict(cd *CompiledDictionary, outPath, sourceName, languageCode string) error {
+	if err := os.MkdirAll(outPath, 0o755); err != nil {
+		return err
+	}
+	if err := removeDictFiles(outPath); err != nil {
+		return err
+	}
+	var files []string
+	f := func(name string) string {
+		files = append(files, name)
+		return filepath.Join(outPath, name)
+	}
+
+	grammemes := make([][]string, len(cd.ParsedDict.Grammemes))
+	aliases := make(map[string]string, len(cd.ParsedDict.Grammemes))
+	for i, g := range cd.ParsedDict.Grammemes {
+		grammemes[i] = []string{g.Name, g.Parent, g.Alias, g.Description}
+		if g.Alias != "" {
+			aliases[g.Name] = g.Alias
+		}
+	}
+	if err := jsonWrite(f("grammemes.json"), grammemes); err != nil {
+		return err
+	}
+
+	cyrGramtab := make([]string, len(cd.Gramtab))
+	for i, t := range cd.Gramtab {
+		cyrGramtab[i] = tagset.TranslateTag(t, aliases)
+	}
+	gramtabFormats := map[string]string{}
+	for format, gramtab := range map[string][]string{
+		pymorphy2GramtabFormat:    cd.Gramtab,
+		pymorphy2CyrGramtabFormat: cyrGramtab,
+	} {
+		name := "gramtab-" + format + ".json"
+		gramtabFormats[format] = name
+		if err := jsonWrite(f(name), gramtab); err != nil {
+			return err
+		}
+	}
+
+	if err := writeParadigmsArray(f("paradigms.array"), cd.Paradigms); err != nil {
+		return err
+	}
+	if err := jsonWrite(f("suffixes.json"), cd.Suffixes); err != nil {
+		return err
+	}
+	if err := cd.WordsDawg.Save(f(Pymorphy2WordsFile)); err != nil {
+		return err
+	}
+	predictionLengths := make([]int, len(cd.PredictionSuffixesDawgs))
+	for i, pd := range cd.PredictionSuffixesDawgs {
+		if err := pd.Save(f(fmt.Sprintf("prediction-suffixes-%d.dawg", i))); err != nil {
+			return err
+		}
+		predictionLengths[i] = len(pd.Data())
+	}
+	if cd.LemmaIDs != nil {
+		if err := cd.LemmaIDs.Save(f(LemmaIDsFile)); err != nil {
+			return err
+		}
+	}
+
+	meta := [][2]any{
+		{"language_code", languageCode},
+		{"format_version", Pymorphy2FormatVersion},
+
+		{"source", sourceName},
+		{"source_version", cd.ParsedDict.Version},
+		{"source_revision", cd.ParsedDict.Revision},
+		{"source_lexemes_count", len(cd.ParsedDict.Lexemes)},
+		{"source_links_count", len(cd.ParsedDict.Links)},
+
+		{"gramtab_length", len(cd.Gramtab)},
+		{"gramtab_formats", gramtabFormats},
+		{"paradigms_length", len(cd.Paradigms)},
+		{"suffixes_length", len(cd.Suffixes)},
+
+		{"words_dawg_length", len(cd.WordsDawg.Data())},
+		{"compile_options", cd.CompileOptions},
+		{"prediction_suffixes_dawg_lengths", predictionLengths},
+	}
+	if !cd.CompiledAt.IsZero() {
+		meta = slices.Insert(meta, 2, [2]any{"compiled_at", cd.CompiledAt.UTC().Format(time.RFC3339)})
+	}
+	hash, err := contentHash(outPath, files)
+	if err != nil {
+		return err
+	}
+	meta = append(meta, [2]any{"content_hash", hash})
+	return jsonWrite(f("meta.json"), meta)
+}
+
+// writeParadigmsArray writes paradigms in the paradigms.array format read by
+// readParadigmsArray.
+func writeParadigmsArray(path string, paradigms [][]uint16) error {
+	if len(paradigms) > math.MaxUint16 {
+		return fmt.Errorf("%s: too many paradigms: %d", path, len(paradigms))
+	}
+	buf := make([]byte, 0, 2)
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(paradigms)))
+	for _, para := range paradigms {
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(len(para)))
+		for _, v := range para {
+			buf = binary.LittleEndian.AppendUint16(buf, v)
+		}
+	}
+	return os.WriteFile(path, buf, 0o644)
+}
+
+// readParadigmsArray reads paradigms.array: uint16 paradigms count, then
+// for every paradigm uint16 length followed by its uint16 values.
+func readParadigmsArray(path string) ([][]uint16, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	var count uint16
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	res := make([][]uint16, count)
+	for i := range res {
+		var n uint16
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		para := make([]uint16, n)
+		if err := binary.Read(r, binary.LittleEndian, para); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		res[i] = para
+	}
+	return res, nil
+}
+
+// readMeta reads meta.json. pymorphy2 stores meta as a list of [key, value]
+// pairs, Go-compiled dictionaries as an object.
+func readMeta(path string) (map[string]any, error) {
+	var raw any
+	if err := jsonRead(path, &raw); err != nil {
+		return nil, err
+	}
+	switch v := raw.(type) {
+	case map[string]any:
+		return v, nil
+	case []any:
+		meta := make(map[string]any, len(v))
+		for _, item := range v {
+			pair, ok := item.([]any)
+			if !ok || len(pair) != 2 {
+				return nil, fmt.Errorf("%s: invalid meta entry %v", path, item)
+			}
+			key, ok := pair[0].(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: invalid meta key %v", path, pair[0])
+			}
+			meta[key] = pair[1]
+		}
+		return meta, nil
+	}
+	return nil, fmt.Errorf("%s: invalid meta format", path)
+}
+
+// readGrammemes reads grammemes.json. pymorphy2 stores every grammeme as a
+// [name, parent, alias, description] list, Go-compiled dictionaries as an
+// object.
+func readGrammemes(path string) ([]Grammeme, error) {
+	var raw []json.RawMessage
+	if err := jsonRead(path, &raw); err != nil {
+		return nil, err
+	}
+	res := make([]Grammeme, 0, len(raw))
+	for _, item := range raw {
+		var fields []*string
+		if err := json.Unmarshal(item, &fields); err == nil {
+			var g Grammeme
+			for i, dst := range []*string{&g.Name, &g.Parent, &g.Alias, &g.Description} {
+				if i < len(fields) && fields[i] != nil {
+					*dst = *fields[i]
+				}
+			}
+			res = append(res, g)
+			continue
+		}
+		var g Grammeme
+		if err := json.Unmarshal(item, &g); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		res = append(res, g)
+	}
+	return res, nil
+}
@@ -0,0 +1,217 @@
+package dict
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"morphy/pkg/dawg"
+)
+
+func TestSavePymorphy2Dict(t *testing.T) {
+	path := t.TempDir()
+	compiled := compileTestDict(t, nil, nil)
+	if err := SavePymorphy2Dict(compiled, path, "test", "ru"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"words.dawg", "paradigms.array", "prediction-suffixes-0.dawg", "gramtab-opencorpora-int.json"} {
+		if _, err := os.Stat(filepath.Join(path, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := LoadDict(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Meta["format_version"] != Pymorphy2FormatVersion {
+		t.Errorf("format_version = %v", got.Meta["format_version"])
+	}
+	if !reflect.DeepEqual(got.Paradigms, compiled.Paradigms) || !reflect.DeepEqual(got.Suffixes, compiled.Suffixes) {
+		t.Errorf("paradigms or suffixes differ")
+	}
+	if !reflect.DeepEqual(got.ParadigmPrefixes, compiled.ParadigmPrefixes) {
+		t.Errorf("paradigm prefixes = %v, want %v", got.ParadigmPrefixes, compiled.ParadigmPrefixes)
+	}
+	sameValues := func(a, b map[string][]dawg.WordForm) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			x, y := append([]dawg.WordForm(nil), v...), append([]dawg.WordForm(nil), b[k]...)
+			less := func(s []dawg.WordForm) func(i, j int) bool {
+				return func(i, j int) bool {
+					return s[i].ParadigmID < s[j].ParadigmID || s[i].ParadigmID == s[j].ParadigmID && s[i].FormIndex < s[j].FormIndex
+				}
+			}
+			sort.Slice(x, less(x))
+			sort.Slice(y, less(y))
+			if !reflect.DeepEqual(x, y) {
+				return false
+			}
+		}
+		return true
+	}
+	if !sameValues(got.Words.Data(), compiled.WordsDawg.Data()) {
+		t.Errorf("words differ: %v != %v", got.Words.Data(), compiled.WordsDawg.Data())
+	}
+	if len(got.PredictionSuffixes) != len(compiled.PredictionSuffixesDawgs) {
+		t.Fatalf("prediction tables count differs")
+	}
+	for i, pd := range got.PredictionSuffixes {
+		if len(pd.Data()) != len(compiled.PredictionSuffixesDawgs[i].Data()) {
+			t.Errorf("prediction table %d differs", i)
+		}
+	}
+}
+
+// TestLoadPymorphy2Fixture reads a dictionary written by pymorphy2's own
+// compile and storage code (see testdata/gen_pymorphy2.py).
+func TestLoadPymorphy2Fixture(t *testing.T) {
+	path := filepath.Join("testdata", "pymorphy2")
+	ld, err := LoadDict(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ld.Meta["language_code"] != "ru" || ld.Meta["format_version"] != "2.4" || ld.Meta["words_dawg_length"] != 11.0 {
+		t.Errorf("unexpected meta %v", ld.Meta)
+	}
+	if want := []string{"", "по", "наи"}; !reflect.DeepEqual(ld.ParadigmPrefixes, want) {
+		t.Errorf("paradigm prefixes = %q, want %q", ld.ParadigmPrefixes, want)
+	}
+	if len(ld.Paradigms) != 4 || len(ld.Suffixes) != 7 || len(ld.Gramtab) != 9 {
+		t.Fatalf("%d paradigms, %d suffixes, %d tags", len(ld.Paradigms), len(ld.Suffixes), len(ld.Gramtab))
+	}
+
+	form := func(wf dawg.WordForm) string {
+		para := ld.Paradigms[wf.ParadigmID]
+		n := len(para) / 3
+		i := int(wf.FormIndex)
+		return ld.ParadigmPrefixes[para[2*n+i]] + "|" + ld.Suffixes[para[i]] + "|" + ld.Gramtab[para[n+i]].String()
+	}
+	want := map[string][]string{
+		"мама":       {"|а|NOUN,anim,femn sing,nomn"},
+		"рамы":       {"|ы|NOUN,anim,femn sing,gent"},
+		"стать":      {"|ть|INFN,perf,intr"},
+		"наибольший": {"наи||ADJF,Qual Supr,masc,sing,nomn"},
+		"стали": {
+			"|и|NOUN,inan,femn sing,gent",
+			"|и|NOUN,inan,femn plur,nomn",
+			"|ли|VERB,perf,intr plur,past,indc",
+		},
+	}
+	data := ld.Words.Data()
+	if len(data) != 9 {
+		t.Errorf("%d words, want 9", len(data))
+	}
+	for word, forms := range want {
+		var got []string
+		for _, wf := range data[word] {
+			got = append(got, form(wf))
+		}
+		sort.Strings(got)
+		sort.Strings(forms)
+		if !reflect.DeepEqual(got, forms) {
+			t.Errorf("forms of %q = %q, want %q", word, got, forms)
+		}
+	}
+
+	predictions := ld.PredictionSuffixes[0].Data()
+	if len(predictions) != 10 || len(ld.PredictionSuffixes[1].Data()) != 0 {
+		t.Errorf("prediction tables: %v, %v", predictions, ld.PredictionSuffixes[1].Data())
+	}
+	if got := predictions["ама"]; len(got) != 1 || got[0].Count != 2 || form(dawg.WordForm{ParadigmID: got[0].ParadigmID, FormIndex: got[0].FormIndex}) != "|а|NOUN,anim,femn sing,nomn" {
+		t.Errorf(`predictions for "ама" = %v`, got)
+	}
+
+	cpd, err := dawg.LoadConditionalProbDist(filepath.Join(path, "p_t_given_w.intdawg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	probs := map[string]float64{
+		"VERB,perf,intr plur,past,indc": 0.8,
+		"NOUN,inan,femn sing,gent":      0.15,
+		"NOUN,inan,femn plur,nomn":      0.05,
+	}
+	if got := cpd.Probs("стали"); !reflect.DeepEqual(got, probs) {
+		t.Errorf("Probs(стали) = %v, want %v", got, probs)
+	}
+	if got := cpd.Prob("мама", "NOUN,anim,femn sing,nomn"); got != 0 {
+		t.Errorf("Prob(мама) = %v, want 0", got)
+	}
+}
+
+// TestRebuildPymorphy2Fixture rebuilds the DAWG files of the fixture from
+// their contents and compares them byte for byte.
+func TestRebuildPymorphy2Fixture(t *testing.T) {
+	path := filepath.Join("testdata", "pymorphy2")
+	ld, err := LoadDict(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := t.TempDir()
+	save := map[string]func(string) error{
+		"words.dawg": dawg.NewWordsDawg(ld.Words.Data()).Save,
+		// the probabilities gen_pymorphy2.py stores
+		"p_t_given_w.intdawg": func(path string) error {
+			return dawg.WriteConditionalProbDist(path, map[string]map[string]float64{"стали": {
+				"VERB,perf,intr plur,past,indc": 0.8,
+				"NOUN,inan,femn sing,gent":      0.15,
+				"NOUN,inan,femn plur,nomn":      0.05,
+			}})
+		},
+	}
+	for i, pd := range ld.PredictionSuffixes {
+		save[fmt.Sprintf("prediction-suffixes-%d.dawg", i)] = dawg.NewPredictionSuffixesDAWG(pd.Data()).Save
+	}
+	for name, fn := range save {
+		if err := fn(filepath.Join(out, name)); err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile(filepath.Join(path, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			n := 0
+			for i := range got {
+				if i < len(want) && got[i] != want[i] {
+					n++
+				}
+			}
+			t.Errorf("%s: %d bytes, want %d; %d bytes differ", name, len(got), len(want), n)
+		}
+	}
+}
+
+// TestSaveOverOtherLayout checks that files of an earlier build in another
+// layout are removed and not loaded with the new ones.
+func TestSaveOverOtherLayout(t *testing.T) {
+	path := t.TempDir()
+	compiled := compileTestDict(t, nil, map[string]any{"lemma_ids": true})
+	if err := SavePymorphy2Dict(compiled, path, "test", "ru"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveCompiledDict(compileTestDict(t, nil, nil), path, "test", "ru"); err != nil {
+		t.Fatal(err)
+	}
+	for _, pattern := range []string{Pymorphy2WordsFile, LemmaIDsFile, "paradigms.array", "prediction-suffixes-*.dawg", "gramtab-*.json"} {
+		if names, _ := filepath.Glob(filepath.Join(path, pattern)); len(names) != 0 {
+			t.Errorf("stale files %v", names)
+		}
+	}
+	ld, err := LoadDict(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ld.Meta["format_version"] != CurrentFormatVersion || ld.LemmaIDs != nil {
+		t.Errorf("stale data loaded: format_version %v, lemma IDs %v", ld.Meta["format_version"], ld.LemmaIDs)
+	}
+}
@@ -1,10 +1,15 @@
 package dict
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"time"
 
 	"morphy/pkg/dawg"
@@ -14,6 +19,10 @@ import (
 // CurrentFormatVersion describes format of saved dictionaries.
 const CurrentFormatVersion = "0.1"
 
+// LemmaIDsFile is the name of the optional source lemma IDs file (see
+// CompiledDictionary.LemmaIDs); it is written in every layout.
+const LemmaIDsFile = "lemma-ids.dawg"
+
 // LoadedDictionary holds dictionary data loaded from disk.
 type LoadedDictionary struct {
 	Meta               map[string]any
@@ -23,26 +32,79 @@ type LoadedDictionary struct {
 	Words              *dawg.WordsDawg
 	PredictionSuffixes []*dawg.PredictionSuffixesDAWG
 	ParadigmPrefixes   []string
+	// LemmaIDs is nil if the dictionary has no LemmaIDsFile.
+	LemmaIDs *dawg.LemmaIDsDAWG
+	release  func() error
 }
 
-// LoadDict reads dictionary data from path.
+// Close releases memory-mapped data of a binary dictionary. Dictionary data
+// must not be used after Close.
+func (ld *LoadedDictionary) Close() error {
+	if ld.release == nil {
+		return nil
+	}
+	err := ld.release()
+	ld.release = nil
+	return err
+}
+
+// LoadDict reads dictionary data from path. Binary data (BinaryDictFile) is
+// used when present, then the native pymorphy2 layout (Pymorphy2WordsFile),
+// JSON files otherwise. Binary data converted before the dictionary was
+// recompiled in place is an error.
 func LoadDict(path string) (*LoadedDictionary, error) {
+	return loadDict(path, true)
+}
+
+// loadDict reads dictionary data from path, ignoring BinaryDictFile unless
+// useBinary is set.
+func loadDict(path string, useBinary bool) (*LoadedDictionary, error) {
 	f := func(name string) string { return filepath.Join(path, name) }
-	meta := map[string]any{}
-	if err := jsonRead(f("meta.json"), &meta); err != nil {
+	meta, err := readMeta(f("meta.json"))
+	if err != nil {
 		return nil, err
 	}
 
 	// load grammemes to register in tagset
-	var grammemes []string
-	_ = jsonRead(f("grammemes.json"), &grammemes)
+	grammemes, err := readGrammemes(f("grammemes.json"))
+	if err != nil {
+		return nil, err
+	}
 	for _, g := range grammemes {
-		tagset.AddGrammemeToKnown(g, g, true)
+		cyr := g.Alias
+		if cyr == "" {
+			cyr = g.Name
+		}
+		tagset.AddGrammemeToKnown(g.Name, cyr, true)
+	}
+
+	ld := &LoadedDictionary{Meta: meta}
+	if _, err := os.Stat(f(LemmaIDsFile)); err == nil {
+		if ld.LemmaIDs, err = dawg.LoadLemmaIDsDAWG(f(LemmaIDsFile)); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := os.Stat(f(BinaryDictFile)); err == nil && useBinary {
+		if err := checkBinaryDict(path, meta); err != nil {
+			return nil, err
+		}
+		if err := loadBinaryDict(path, ld); err != nil {
+			return nil, err
+		}
+		return ld, nil
+	}
+	if _, err := os.Stat(f(Pymorphy2WordsFile)); err == nil {
+		if err := loadPymorphy2Dict(path, ld); err != nil {
+			return nil, err
+		}
+		return ld, nil
 	}
 
 	// load gramtab
 	var gramtabStr []string
-	_ = jsonRead(f("gramtab.json"), &gramtabStr)
+	if err := jsonRead(f("gramtab.json"), &gramtabStr); err != nil {
+		return nil, err
+	}
 	gramtab := make([]tagset.Tag, 0, len(gramtabStr))
 	for _, t := range gramtabStr {
 		tg, err := tagset.New(t)
@@ -54,20 +116,28 @@ func LoadDict(path string) (*LoadedDictionary, error) {
 
 	// load suffixes
 	var suffixes []string
-	_ = jsonRead(f("suffixes.json"), &suffixes)
+	if err := jsonRead(f("suffixes.json"), &suffixes); err != nil {
+		return nil, err
+	}
 
 	// load paradigms
 	var paradigms [][]uint16
-	_ = jsonRead(f("paradigms.json"), &paradigms)
+	if err := jsonRead(f("paradigms.json"), &paradigms); err != nil {
+		return nil, err
+	}
 
 	// load words
 	wordsMap := map[string][]dawg.WordForm{}
-	_ = jsonRead(f("words.json"), &wordsMap)
+	if err := jsonRead(f("words.json"), &wordsMap); err != nil {
+		return nil, err
+	}
 	words := dawg.NewWordsDawg(wordsMap)
 
 	// load paradigm prefixes
 	var paradigmPrefixes []string
-	_ = jsonRead(f("paradigm-prefixes.json"), &paradigmPrefixes)
+	if err := jsonRead(f("paradigm-prefixes.json"), &paradigmPrefixes); err != nil {
+		return nil, err
+	}
 
 	// load prediction suffix dawgs if present
 	prediction := []*dawg.PredictionSuffixesDAWG{}
@@ -83,23 +153,58 @@ func LoadDict(path string) (*LoadedDictionary, error) {
 		prediction = append(prediction, dawg.NewPredictionSuffixesDAWG(data))
 	}
 
-	return &LoadedDictionary{
-		Meta:               meta,
-		Gramtab:            gramtab,
-		Suffixes:           suffixes,
-		Paradigms:          paradigms,
-		Words:              words,
-		PredictionSuffixes: prediction,
-		ParadigmPrefixes:   paradigmPrefixes,
-	}, nil
+	ld.Gramtab = gramtab
+	ld.Suffixes = suffixes
+	ld.Paradigms = paradigms
+	ld.Words = words
+	ld.PredictionSuffixes = prediction
+	ld.ParadigmPrefixes = paradigmPrefixes
+	return ld, nil
+}
+
+// dictFilePatterns match the files of every dictionary layout, including
+// data added to a compiled dictionary: P(t|w) and binary conversion.
+var dictFilePatterns = []string{
+	"meta.json", "grammemes.json", "gramtab.json", "gramtab-*.json",
+	"suffixes.json", "paradigms.json", "paradigms.array", "paradigm-prefixes.json",
+	"words.json", Pymorphy2WordsFile, "prediction-suffixes-*.json", "prediction-suffixes-*.dawg",
+	LemmaIDsFile, BinaryDictFile, "p_t_given_w.intdawg",
 }
 
-// SaveCompiledDict saves compiled dictionary to outPath.
+// removeDictFiles removes dictionary files of every layout from path, so
+// files of an earlier build are not loaded together with the new ones.
+func removeDictFiles(path string) error {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		for _, pattern := range dictFilePatterns {
+			if ok, _ := filepath.Match(pattern, e.Name()); ok {
+				if err := os.Remove(filepath.Join(path, e.Name())); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// SaveCompiledDict saves compiled dictionary to outPath. Dictionary files
+// already in outPath are removed first.
 func SaveCompiledDict(cd *CompiledDictionary, outPath, sourceName, languageCode string) error {
 	if err := os.MkdirAll(outPath, 0o755); err != nil {
 		return err
 	}
-	f := func(name string) string { return filepath.Join(outPath, name) }
+	if err := removeDictFiles(outPath); err != nil {
+		return err
+	}
+	var files []string
+	f := func(name string) string {
+		files = append(files, name)
+		return filepath.Join(outPath, name)
+	}
 
 	if err := jsonWrite(f("grammemes.json"), cd.ParsedDict.Grammemes); err != nil {
 		return err
@@ -125,22 +230,111 @@ func SaveCompiledDict(cd *CompiledDictionary, outPath, sourceName, languageCode
 			return err
 		}
 	}
+	if cd.LemmaIDs != nil {
+		if err := cd.LemmaIDs.Save(f(LemmaIDsFile)); err != nil {
+			return err
+		}
+	}
 
 	meta := map[string]any{
-		"language_code":  languageCode,
-		"format_version": CurrentFormatVersion,
-		"compiled_at":    time.Now().UTC().Format(time.RFC3339),
-		"source":         sourceName,
+		"language_code":   languageCode,
+		"format_version":  CurrentFormatVersion,
+		"source":          sourceName,
+		"source_version":  cd.ParsedDict.Version,
+		"source_revision": cd.ParsedDict.Revision,
+		"compile_options": cd.CompileOptions,
+
+		"source_lexemes_count": len(cd.ParsedDict.Lexemes),
+		"source_links_count":   len(cd.ParsedDict.Links),
+		"lexemes_count":        cd.LexemesCount,
+		"gramtab_length":       len(cd.Gramtab),
+		"paradigms_length":     len(cd.Paradigms),
+		"suffixes_length":      len(cd.Suffixes),
+	}
+	if !cd.CompiledAt.IsZero() {
+		meta["compiled_at"] = cd.CompiledAt.UTC().Format(time.RFC3339)
+	}
+	hash, err := contentHash(outPath, files)
+	if err != nil {
+		return err
 	}
+	meta["content_hash"] = hash
 	return jsonWrite(f("meta.json"), meta)
 }
 
+// UpdateMeta adds or replaces meta.json entries of dictionary at path. The
+// file layout is kept: a list of [key, value] pairs for pymorphy2
+// dictionaries, an object otherwise.
+func UpdateMeta(path string, extra map[string]any) error {
+	name := filepath.Join(path, "meta.json")
+	var raw any
+	if err := jsonRead(name, &raw); err != nil {
+		return err
+	}
+	switch v := raw.(type) {
+	case map[string]any:
+		for k, val := range extra {
+			v[k] = val
+		}
+	case []any:
+		seen := map[string]bool{}
+		for _, item := range v {
+			pair, ok := item.([]any)
+			if !ok || len(pair) != 2 {
+				return fmt.Errorf("%s: invalid meta entry %v", name, item)
+			}
+			if key, ok := pair[0].(string); ok {
+				if val, ok := extra[key]; ok {
+					pair[1] = val
+					seen[key] = true
+				}
+			}
+		}
+		keys := make([]string, 0, len(extra))
+		for k := range extra {
+			if !seen[k] {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v = append(v, []any{k, extra[k]})
+		}
+		raw = v
+	default:
+		return fmt.Errorf("%s: invalid meta format", name)
+	}
+	return jsonWrite(name, raw)
+}
+
 func jsonRead(path string, v any) error {
 	b, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(b, v)
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// contentHash returns "sha256:<hex>" digest of the named files of dictionary
+// at dir, taken in name order. It is recorded in meta.json as content_hash
+// and covers all files written on compilation except meta.json itself.
+func contentHash(dir string, names []string) (string, error) {
+	names = slices.Sorted(slices.Values(names))
+	h := sha256.New()
+	for _, name := range names {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return "", err
+		}
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write(binary.LittleEndian.AppendUint64(nil, uint64(len(b))))
+		h.Write(b)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
 }
 
 func jsonWrite(path string, v any) error {
@@ -0,0 +1,70 @@
+# -*- coding: utf-8 -*-
+"""
+Regenerate the pymorphy2/ fixture from pymorphy2.xml with pymorphy2's own
+compile and storage code (the Python sources at the repository root):
+
+    python3 gen_pymorphy2.py
+
+The .dawg files are written by the ``DAWG`` package, which must be
+installed (``pip install DAWG``).
+"""
+from __future__ import absolute_import, unicode_literals
+import os
+import shutil
+import sys
+import types
+
+HERE = os.path.dirname(os.path.abspath(__file__))
+ROOT = os.path.abspath(os.path.join(HERE, '..', '..', '..'))
+OUT = os.path.join(HERE, 'pymorphy2')
+
+# The repository root is the pymorphy2 package; load its modules without
+# running pymorphy2/__init__.py, which imports the analyzer and all languages.
+package = types.ModuleType(str('pymorphy2'))
+package.__path__ = [ROOT]
+sys.modules['pymorphy2'] = package
+from pymorphy2.version import __version__
+package.__version__ = __version__
+
+from pymorphy2.dawg import ConditionalProbDistDAWG
+from pymorphy2.opencorpora_dict.compile import compile_parsed_dict
+from pymorphy2.opencorpora_dict.parse import parse_opencorpora_xml
+from pymorphy2.opencorpora_dict.preprocess import simplify_tags, drop_unsupported_parses
+from pymorphy2.opencorpora_dict.storage import save_compiled_dict, update_meta
+
+# pymorphy2.lang.ru.PARADIGM_PREFIXES
+PARADIGM_PREFIXES = ["", "по", "наи"]
+
+# P(t|w) for the ambiguous "стали", as build_cpd_dawg would store it
+PROBABILITIES = [
+    (("стали", "VERB,perf,intr plur,past,indc"), 0.8),
+    (("стали", "NOUN,inan,femn sing,gent"), 0.15),
+    (("стали", "NOUN,inan,femn plur,nomn"), 0.05),
+]
+
+
+def main():
+    if os.path.exists(OUT):
+        shutil.rmtree(OUT)
+    os.makedirs(OUT)
+
+    parsed_dict = parse_opencorpora_xml(os.path.join(HERE, 'pymorphy2.xml'))
+    simplify_tags(parsed_dict)
+    drop_unsupported_parses(parsed_dict)
+    compiled_dict = compile_parsed_dict(parsed_dict, dict(
+        paradigm_prefixes=PARADIGM_PREFIXES,
+        min_paradigm_popularity=2,
+    ))
+    save_compiled_dict(compiled_dict, OUT, source_name='test', language_code='ru')
+
+    ConditionalProbDistDAWG(PROBABILITIES).save(os.path.join(OUT, 'p_t_given_w.intdawg'))
+    update_meta(os.path.join(OUT, 'meta.json'), [
+        ('P(t|w)', True),
+        ('P(t|w)_unique_words', 1),
+        ('P(t|w)_outcomes', 20),
+        ('P(t|w)_min_word_freq', 1),
+    ])
+
+
+if __name__ == '__main__':
+    main()
@@ -0,0 +1,219 @@
+package dict
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"morphy/pkg/dawg"
+)
+
+// Kinds of integrity problems reported by Validate. IntegrityError wraps one
+// of them, so they can be matched with errors.Is.
+var (
+	ErrMissingData    = errors.New("missing dictionary data")
+	ErrFormatVersion  = errors.New("unsupported format version")
+	ErrParadigm       = errors.New("malformed paradigm")
+	ErrSuffixID       = errors.New("suffix id out of range")
+	ErrTagID          = errors.New("tag id out of range")
+	ErrPrefixID       = errors.New("prefix id out of range")
+	ErrParadigmID     = errors.New("paradigm id out of range")
+	ErrFormIndex      = errors.New("form index out of range")
+	ErrWordMismatch   = errors.New("word does not match prefix and suffix of its form")
+	ErrPredictionData = errors.New("invalid prediction data")
+)
+
+// maxIntegrityErrors limits the number of problems collected by Validate.
+const maxIntegrityErrors = 100
+
+// IntegrityError describes a structural problem of dictionary data.
+type IntegrityError struct {
+	// Err is one of the Err* kinds above.
+	Err error
+	// Where locates the problem, e.g. `paradigm 12` or `word "мама"`.
+	Where string
+	// Detail holds offending values.
+	Detail string
+}
+
+func (e *IntegrityError) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("%s: %v", e.Where, e.Err)
+	}
+	return fmt.Sprintf("%s: %v (%s)", e.Where, e.Err, e.Detail)
+}
+
+func (e *IntegrityError) Unwrap() error { return e.Err }
+
+// ValidateDict loads dictionary at path without validation and checks it
+// with Validate. Unlike loading, it walks all words and predictions.
+func ValidateDict(path string) error {
+	ld, err := LoadDict(path)
+	if err != nil {
+		return err
+	}
+	defer ld.Close()
+	return Validate(ld)
+}
+
+// Validate checks that every ID stored in dictionary data refers to an
+// existing entry: suffix, tag and prefix IDs of paradigms, paradigm IDs and
+// form indexes of words and predictions, paradigm IDs of lemma IDs. It also
+// checks that stored words have the prefix and suffix of their forms and
+// that meta declares a supported format_version. Problems are returned as
+// *IntegrityError values joined with errors.Join; at most 100 of them are
+// reported.
+func Validate(ld *LoadedDictionary) error {
+	v := &validator{ld: ld}
+	v.checkMeta()
+	v.checkParadigms()
+	if ld.Words == nil {
+		v.fail(ErrMissingData, "words", "")
+	} else {
+		ld.Words.Walk("", func(word string, forms []dawg.WordForm) bool {
+			for _, wf := range forms {
+				v.checkWord(word, wf)
+			}
+			return len(v.errs) < maxIntegrityErrors
+		})
+	}
+	v.checkPredictions()
+	if ld.LemmaIDs != nil {
+		ld.LemmaIDs.Walk("", func(normal string, refs []dawg.LemmaRef) bool {
+			for _, ref := range refs {
+				v.form(fmt.Sprintf("lemma ID %d", ref.LemmaID), ref.ParadigmID, 0)
+			}
+			return len(v.errs) < maxIntegrityErrors
+		})
+	}
+	return errors.Join(v.errs...)
+}
+
+// validateHeader runs the checks of Validate which do not depend on the
+// number of words: meta and paradigms. It is done on every load.
+func validateHeader(ld *LoadedDictionary) error {
+	v := &validator{ld: ld}
+	v.checkMeta()
+	v.checkParadigms()
+	if ld.Words == nil {
+		v.fail(ErrMissingData, "words", "")
+	}
+	return errors.Join(v.errs...)
+}
+
+type validator struct {
+	ld *LoadedDictionary
+	// paradigms which passed checkParadigms
+	valid []bool
+	errs  []error
+}
+
+func (v *validator) fail(kind error, where, detail string, args ...any) {
+	if len(v.errs) >= maxIntegrityErrors {
+		return
+	}
+	v.errs = append(v.errs, &IntegrityError{Err: kind, Where: where, Detail: fmt.Sprintf(detail, args...)})
+}
+
+// supportedFormatVersion reports whether meta format_version is one of the
+// JSON layout (CurrentFormatVersion) or has the major version of the
+// pymorphy2 format.
+func supportedFormatVersion(version string) bool {
+	if version == CurrentFormatVersion {
+		return true
+	}
+	major, _, _ := strings.Cut(Pymorphy2FormatVersion, ".")
+	return strings.HasPrefix(version, major+".")
+}
+
+func (v *validator) checkMeta() {
+	version, ok := v.ld.Meta["format_version"].(string)
+	if !ok {
+		v.fail(ErrFormatVersion, "meta", "format_version is missing")
+		return
+	}
+	if !supportedFormatVersion(version) {
+		v.fail(ErrFormatVersion, "meta", "format_version %q", version)
+	}
+}
+
+func (v *validator) checkParadigms() {
+	ld := v.ld
+	v.valid = make([]bool, len(ld.Paradigms))
+	for id, p := range ld.Paradigms {
+		where := fmt.Sprintf("paradigm %d", id)
+		if len(p) == 0 || len(p)%3 != 0 {
+			v.fail(ErrParadigm, where, "length %d", len(p))
+			continue
+		}
+		n := len(p) / 3
+		ok := true
+		for i := 0; i < n; i++ {
+			if int(p[i]) >= len(ld.Suffixes) {
+				v.fail(ErrSuffixID, where, "form %d: suffix %d of %d", i, p[i], len(ld.Suffixes))
+				ok = false
+			}
+			if int(p[n+i]) >= len(ld.Gramtab) {
+				v.fail(ErrTagID, where, "form %d: tag %d of %d", i, p[n+i], len(ld.Gramtab))
+				ok = false
+			}
+			if int(p[2*n+i]) >= len(ld.ParadigmPrefixes) {
+				v.fail(ErrPrefixID, where, "form %d: prefix %d of %d", i, p[2*n+i], len(ld.ParadigmPrefixes))
+				ok = false
+			}
+		}
+		v.valid[id] = ok
+	}
+}
+
+// form returns prefix and suffix of a form, reporting references to missing
+// or broken paradigms.
+func (v *validator) form(where string, paraID, idx uint16) (prefix, suffix string, ok bool) {
+	if int(paraID) >= len(v.ld.Paradigms) {
+		v.fail(ErrParadigmID, where, "paradigm %d of %d", paraID, len(v.ld.Paradigms))
+		return "", "", false
+	}
+	if !v.valid[paraID] {
+		return "", "", false
+	}
+	p := v.ld.Paradigms[paraID]
+	n := len(p) / 3
+	if int(idx) >= n {
+		v.fail(ErrFormIndex, where, "paradigm %d: form %d of %d", paraID, idx, n)
+		return "", "", false
+	}
+	return v.ld.ParadigmPrefixes[p[2*n+int(idx)]], v.ld.Suffixes[p[idx]], true
+}
+
+func (v *validator) checkWord(word string, wf dawg.WordForm) {
+	where := fmt.Sprintf("word %q", word)
+	prefix, suffix, ok := v.form(where, wf.ParadigmID, wf.FormIndex)
+	if !ok {
+		return
+	}
+	if len(word) < len(prefix)+len(suffix) || !strings.HasPrefix(word, prefix) || !strings.HasSuffix(word, suffix) {
+		v.fail(ErrWordMismatch, where, "paradigm %d, form %d: prefix %q, suffix %q", wf.ParadigmID, wf.FormIndex, prefix, suffix)
+	}
+}
+
+// checkPredictions checks prediction tables: there is at most one table per
+// paradigm prefix, and an ending predicts forms whose suffix is its suffix or
+// ends with it.
+func (v *validator) checkPredictions() {
+	ld := v.ld
+	if len(ld.PredictionSuffixes) > len(ld.ParadigmPrefixes) {
+		v.fail(ErrPredictionData, "prediction tables", "%d tables for %d paradigm prefixes", len(ld.PredictionSuffixes), len(ld.ParadigmPrefixes))
+	}
+	for i, pd := range ld.PredictionSuffixes {
+		pd.Walk("", func(ending string, preds []dawg.Prediction) bool {
+			where := fmt.Sprintf("prediction table %d, ending %q", i, ending)
+			for _, p := range preds {
+				_, suffix, ok := v.form(where, p.ParadigmID, p.FormIndex)
+				if ok && !strings.HasSuffix(ending, suffix) && !strings.HasSuffix(suffix, ending) {
+					v.fail(ErrPredictionData, where, "paradigm %d, form %d: suffix %q", p.ParadigmID, p.FormIndex, suffix)
+				}
+			}
+			return len(v.errs) < maxIntegrityErrors
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package dict
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"morphy/pkg/dawg"
+)
+
+func TestValidate(t *testing.T) {
+	path := saveTestDict(t, compileTestDict(t, nil, nil))
+	if err := ValidateDict(path); err != nil {
+		t.Fatal(err)
+	}
+
+	ld, err := LoadDict(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ld.Meta["format_version"] = "9.0"
+	ld.Suffixes = ld.Suffixes[:0]
+	err = Validate(ld)
+	for _, kind := range []error{ErrFormatVersion, ErrSuffixID} {
+		if !errors.Is(err, kind) {
+			t.Errorf("expected %v, got %v", kind, err)
+		}
+	}
+	var ie *IntegrityError
+	if !errors.As(err, &ie) || ie.Where != "meta" {
+		t.Errorf("unexpected first error: %v", ie)
+	}
+
+	ld, err = LoadDict(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := ld.Paradigms[0]
+	n := len(p) / 3
+	ld.Paradigms[0] = []uint16{p[0], p[n], p[2*n]}
+	if err := Validate(ld); !errors.Is(err, ErrFormIndex) {
+		t.Errorf("expected form index error, got %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(path, "gramtab.json")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewDictionary(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected missing gramtab error, got %v", err)
+	}
+}
+
+func TestNewDictionaryValidation(t *testing.T) {
+	compiled := compileTestDict(t, nil, nil)
+	data := compiled.WordsDawg.Data()
+	data["мамаа"] = []dawg.WordForm{{ParadigmID: 0, FormIndex: 999}}
+	compiled.WordsDawg = dawg.NewWordsDawg(data)
+	path := saveTestDict(t, compiled)
+
+	// words are not walked by default
+	openTestDict(t, path)
+	if _, err := NewDictionaryWithOptions(path, DictionaryOptions{FullValidation: true}); !errors.Is(err, ErrFormIndex) {
+		t.Errorf("expected form index error, got %v", err)
+	}
+	if err := ValidateDict(path); !errors.Is(err, ErrFormIndex) {
+		t.Errorf("expected form index error, got %v", err)
+	}
+}
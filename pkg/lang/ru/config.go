@@ -1,9 +1,12 @@
 package ru
 
-import "morphy/pkg/units"
+import (
+	"morphy/pkg/dict"
+	"morphy/pkg/units"
+)
 
 // ParadigmPrefixes are prefixes used for dictionary compilation.
-var ParadigmPrefixes = []string{"", "по", "наи"}
+var ParadigmPrefixes = dict.LanguageParadigmPrefixes("ru")
 
 // InitialLetters are letters that initials can start with.
 const InitialLetters = "АБВГДЕЁЖЗИЙКЛМНОПРСТУФХЦЧШЩЭЮЯ"
@@ -0,0 +1,61 @@
+package ru
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"morphy/pkg/dict"
+)
+
+const testDictXML = `<?xml version="1.0" encoding="utf-8"?>
+<dictionary version="0.1" revision="1">
+<grammemes>
+<grammeme parent=""><name>Supr</name><alias>прев</alias><description></description></grammeme>
+<grammeme parent=""><name>Qual</name><alias>кач</alias><description></description></grammeme>
+</grammemes>
+<lemmata>
+<lemma id="1"><l t="больший"><g v="ADJF"/><g v="Qual"/></l><f t="больший"><g v="masc"/><g v="sing"/><g v="nomn"/></f><f t="большего"><g v="masc"/><g v="sing"/><g v="gent"/></f><f t="наибольший"><g v="Supr"/><g v="masc"/><g v="sing"/><g v="nomn"/></f><f t="наибольшего"><g v="Supr"/><g v="masc"/><g v="sing"/><g v="gent"/></f></lemma>
+<lemma id="2"><l t="меньший"><g v="ADJF"/><g v="Qual"/></l><f t="меньший"><g v="masc"/><g v="sing"/><g v="nomn"/></f><f t="меньшего"><g v="masc"/><g v="sing"/><g v="gent"/></f><f t="наименьший"><g v="Supr"/><g v="masc"/><g v="sing"/><g v="nomn"/></f><f t="наименьшего"><g v="Supr"/><g v="masc"/><g v="sing"/><g v="gent"/></f></lemma>
+</lemmata>
+</dictionary>`
+
+// TestParadigmPrefixes compiles a dictionary without the "paradigm_prefixes"
+// option and checks that the prefixes of the language are used.
+func TestParadigmPrefixes(t *testing.T) {
+	dir := t.TempDir()
+	xmlPath, path := filepath.Join(dir, "dict.xml"), filepath.Join(dir, "dict")
+	if err := os.WriteFile(xmlPath, []byte(testDictXML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := dict.ConvertToPymorphy2(xmlPath, path, "test", "ru", false, nil); err != nil {
+		t.Fatal(err)
+	}
+	d, err := dict.NewDictionary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if got := d.ParadigmPrefixes(); !reflect.DeepEqual(got, ParadigmPrefixes) {
+		t.Fatalf("ParadigmPrefixes() = %q, want %q", got, ParadigmPrefixes)
+	}
+	paradigm := func(word string) uint16 {
+		t.Helper()
+		items := d.SimilarItems(word, nil)
+		if len(items) != 1 || len(items[0].Forms) != 1 {
+			t.Fatalf("SimilarItems(%q) = %v", word, items)
+		}
+		return items[0].Forms[0].ParadigmID
+	}
+	want := paradigm("больший")
+	for _, w := range []string{"наибольший", "меньший", "наименьшего"} {
+		if got := paradigm(w); got != want {
+			t.Errorf("paradigm of %q is %d, want %d", w, got, want)
+		}
+	}
+	forms := d.BuildParadigmInfo(int(want))
+	if len(forms) != 4 || forms[2].Prefix != "наи" || forms[2].Suffix != "ий" {
+		t.Errorf("unexpected paradigm %+v", forms)
+	}
+}
@@ -0,0 +1,107 @@
+package uk
+
+import (
+	"morphy/pkg/dict"
+	"morphy/pkg/units"
+)
+
+// ParadigmPrefixes are prefixes used for dictionary compilation.
+var ParadigmPrefixes = dict.LanguageParadigmPrefixes("uk")
+
+// InitialLetters are letters that initials can start with.
+const InitialLetters = "АБВГҐДЕЄЖЗИІЇЙКЛМНОПРСТУФХЦЧШЩЮЯ"
+
+// ParticlesAfterHyphen lists particles attached via hyphen.
+var ParticlesAfterHyphen = []string{"-но", "-таки", "-бо", "-от", "-то"}
+
+// CharSubstitutes defines characters that may be substituted: apostrophe
+// variants used in texts are looked up as the ASCII apostrophe used by
+// dictionaries, e.g. "м’ясо" as "м'ясо".
+var CharSubstitutes = map[rune]rune{
+	'’': '\'',
+	'ʼ': '\'',
+	'‘': '\'',
+	'`': '\'',
+}
+
+// KnownPrefixes are prefixes that don't change word parse.
+var KnownPrefixes = []string{
+	"авіа",
+	"авто",
+	"агро",
+	"анти",
+	"архі",
+	"аудіо",
+	"біо",
+	"вело",
+	"відео",
+	"віце",
+	"гідро",
+	"гіпер",
+	"еко",
+	"екс",
+	"екстра",
+	"електро",
+	"енерго",
+	"етно",
+	"зоо",
+	"інтер",
+	"квазі",
+	"кібер",
+	"кіно",
+	"контр",
+	"космо",
+	"макро",
+	"максі",
+	"мега",
+	"мета",
+	"мікро",
+	"міні",
+	"моно",
+	"мото",
+	"мульти",
+	"нано",
+	"напів",
+	"нео",
+	"пан",
+	"пів",
+	"пост",
+	"псевдо",
+	"радіо",
+	"ретро",
+	"само",
+	"спец",
+	"стерео",
+	"суб",
+	"супер",
+	"теле",
+	"термо",
+	"транс",
+	"ультра",
+	"фото",
+}
+
+// DefaultUnits returns default analyzer units for Ukrainian.
+func DefaultUnits() []interface{} {
+	return []interface{}{
+		[]units.AnalyzerUnit{
+			&units.DictionaryAnalyzer{},
+			units.NewAbbreviatedFirstNameAnalyzer(InitialLetters),
+			units.NewAbbreviatedPatronymicAnalyzer(InitialLetters),
+		},
+		units.NewNumberAnalyzer(),
+		units.NewPunctuationAnalyzer(),
+		[]units.AnalyzerUnit{
+			units.NewRomanNumberAnalyzer(),
+			units.NewLatinAnalyzer(),
+		},
+		units.NewHyphenSeparatedParticleAnalyzer(ParticlesAfterHyphen),
+		units.NewHyphenatedWordsAnalyzer(KnownPrefixes),
+		units.NewKnownPrefixAnalyzer(KnownPrefixes),
+		[]units.AnalyzerUnit{
+			units.NewUnknownPrefixAnalyzer(),
+			units.NewKnownSuffixAnalyzer(),
+		},
+		&units.UnknAnalyzer{},
+	}
+}
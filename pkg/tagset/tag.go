@@ -2,8 +2,12 @@ package tagset
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // Tag represents an OpenCorpora tag.
@@ -225,26 +229,77 @@ var (
 	NON_PRODUCTIVE_GRAMMEMES = newSet("NUMR", "NPRO", "PRED", "PREP", "CONJ", "PRCL", "INTJ", "Apro")
 )
 
+// grammemeRegistry is a snapshot of known grammemes. Snapshots are never
+// modified: registration replaces the whole snapshot, so lookups need no
+// locking.
+type grammemeRegistry struct {
+	known    map[string]struct{}
+	latToCyr map[string]string
+	cyrToLat map[string]string
+}
+
+var (
+	registryMu sync.Mutex
+	registry   atomic.Pointer[grammemeRegistry]
+)
+
+// Registered grammemes and their aliases. The maps are updated by
+// AddGrammemeToKnown; entries added to them directly are not registered.
+//
+// Deprecated: the maps are not safe for concurrent use with
+// AddGrammemeToKnown. Use GrammemeIsKnown, KnownGrammemeNames, Lat2Cyr and
+// Cyr2Lat instead.
 var (
 	KnownGrammemes = map[string]struct{}{}
 	LatToCyr       = map[string]string{}
 	CyrToLat       = map[string]string{}
 )
 
+func grammemes() *grammemeRegistry {
+	if r := registry.Load(); r != nil {
+		return r
+	}
+	return &grammemeRegistry{}
+}
+
+// AddGrammemeToKnown registers grammeme lat with its Cyrillic alias cyr. An
+// already known grammeme is only replaced if overwrite is true. It is safe to
+// call AddGrammemeToKnown concurrently with other functions of the package.
 func AddGrammemeToKnown(lat, cyr string, overwrite bool) {
-	if _, ok := KnownGrammemes[lat]; ok && !overwrite {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	old := grammemes()
+	if _, ok := old.known[lat]; ok && (!overwrite || old.latToCyr[lat] == cyr) {
 		return
 	}
+	r := &grammemeRegistry{
+		known:    maps.Clone(old.known),
+		latToCyr: maps.Clone(old.latToCyr),
+		cyrToLat: maps.Clone(old.cyrToLat),
+	}
+	if r.known == nil {
+		r.known, r.latToCyr, r.cyrToLat = map[string]struct{}{}, map[string]string{}, map[string]string{}
+	}
+	r.known[lat] = struct{}{}
+	r.latToCyr[lat] = cyr
+	r.cyrToLat[cyr] = lat
+	registry.Store(r)
 	KnownGrammemes[lat] = struct{}{}
 	LatToCyr[lat] = cyr
 	CyrToLat[cyr] = lat
 }
 
+// GrammemeIsKnown reports whether grammeme g is registered.
 func GrammemeIsKnown(g string) bool {
-	_, ok := KnownGrammemes[g]
+	_, ok := grammemes().known[g]
 	return ok
 }
 
+// KnownGrammemeNames returns registered grammemes in sorted order.
+func KnownGrammemeNames() []string {
+	return slices.Sorted(maps.Keys(grammemes().known))
+}
+
 func TranslateTag(tag string, mapping map[string]string) string {
 	parts := strings.Fields(tag)
 	for i, part := range parts {
@@ -259,8 +314,8 @@ func TranslateTag(tag string, mapping map[string]string) string {
 	return strings.Join(parts, " ")
 }
 
-func Cyr2Lat(tag string) string { return TranslateTag(tag, CyrToLat) }
-func Lat2Cyr(tag string) string { return TranslateTag(tag, LatToCyr) }
+func Cyr2Lat(tag string) string { return TranslateTag(tag, grammemes().cyrToLat) }
+func Lat2Cyr(tag string) string { return TranslateTag(tag, grammemes().latToCyr) }
 
 func init() {
 	for _, cat := range grammemeCategories {
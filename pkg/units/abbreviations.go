@@ -26,6 +26,12 @@ func NewInitialsAnalyzer(letters, pattern string, score float64) *InitialsAnalyz
 	return &InitialsAnalyzer{letters: letters, tagPattern: pattern, score: score}
 }
 
+// Clone returns a copy of analyzer.
+func (a *InitialsAnalyzer) Clone() AnalyzerUnit {
+	cloned := *a
+	return &cloned
+}
+
 func (a *InitialsAnalyzer) Init(morph Analyzer) {
 	a.BaseAnalyzerUnit.Init(morph)
 	if a.tagPattern == "" {
@@ -83,6 +89,12 @@ func NewAbbreviatedFirstNameAnalyzer(letters string) *AbbreviatedFirstNameAnalyz
 	return &AbbreviatedFirstNameAnalyzer{InitialsAnalyzer: *NewInitialsAnalyzer(letters, "NOUN,anim,%[gender]s,Sgtm,Name,Fixd,Abbr,Init sing,%[case]s", 0.1)}
 }
 
+// Clone returns a copy of analyzer.
+func (a *AbbreviatedFirstNameAnalyzer) Clone() AnalyzerUnit {
+	cloned := *a
+	return &cloned
+}
+
 func (a *AbbreviatedFirstNameAnalyzer) Init(morph Analyzer) {
 	a.InitialsAnalyzer.Init(morph)
 	for _, t := range a.tags {
@@ -123,6 +135,12 @@ func NewAbbreviatedPatronymicAnalyzer(letters string) *AbbreviatedPatronymicAnal
 	return &AbbreviatedPatronymicAnalyzer{InitialsAnalyzer: *NewInitialsAnalyzer(letters, "NOUN,anim,%[gender]s,Sgtm,Patr,Fixd,Abbr,Init sing,%[case]s", 0.1)}
 }
 
+// Clone returns a copy of analyzer.
+func (a *AbbreviatedPatronymicAnalyzer) Clone() AnalyzerUnit {
+	cloned := *a
+	return &cloned
+}
+
 func (a *AbbreviatedPatronymicAnalyzer) Init(morph Analyzer) {
 	a.InitialsAnalyzer.Init(morph)
 	tagset.AddGrammemeToKnown("Patr", "отч", false)
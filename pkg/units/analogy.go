@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"morphy/pkg/analysis"
 	"morphy/pkg/dawg"
@@ -25,6 +26,12 @@ func NewKnownPrefixAnalyzer(prefixes []string) *KnownPrefixAnalyzer {
 	return &KnownPrefixAnalyzer{KnownPrefixes: prefixes, ScoreMultiplier: 0.75, MinRemainder: 3}
 }
 
+// Clone returns a copy of analyzer.
+func (k *KnownPrefixAnalyzer) Clone() AnalyzerUnit {
+	cloned := *k
+	return &cloned
+}
+
 func (k *KnownPrefixAnalyzer) Init(morph Analyzer) {
 	k.BaseAnalyzerUnit.Init(morph)
 	k.matcher = dawg.NewPrefixMatcher(k.KnownPrefixes)
@@ -87,6 +94,12 @@ func NewUnknownPrefixAnalyzer() *UnknownPrefixAnalyzer {
 	return &UnknownPrefixAnalyzer{ScoreMultiplier: 0.5}
 }
 
+// Clone returns a copy of analyzer.
+func (u *UnknownPrefixAnalyzer) Clone() AnalyzerUnit {
+	cloned := *u
+	return &cloned
+}
+
 func (u *UnknownPrefixAnalyzer) Init(morph Analyzer) {
 	u.BaseAnalyzerUnit.Init(morph)
 	da := &DictionaryAnalyzer{}
@@ -146,6 +159,12 @@ func NewKnownSuffixAnalyzer() *KnownSuffixAnalyzer {
 	return &KnownSuffixAnalyzer{ScoreMultiplier: 0.5, MinWordLength: 4}
 }
 
+// Clone returns a copy of analyzer.
+func (k *KnownSuffixAnalyzer) Clone() AnalyzerUnit {
+	cloned := *k
+	return &cloned
+}
+
 func (k *KnownSuffixAnalyzer) Init(morph Analyzer) {
 	k.BaseAnalyzerUnit.Init(morph)
 	dict, _ := k.Dict.(*dict.Dictionary)
@@ -180,11 +199,12 @@ func (k *KnownSuffixAnalyzer) Init(morph Analyzer) {
 }
 
 func (k *KnownSuffixAnalyzer) Parse(word, wordLower string, seen map[string]struct{}) []analysis.Parse {
-	if len(word) < k.MinWordLength {
+	if utf8.RuneCountInString(word) < k.MinWordLength {
 		return nil
 	}
 	dict, _ := k.Dict.(*dict.Dictionary)
 	subs := k.Morph.CharSubstitutes()
+	runes := []rune(wordLower)
 	totalCounts := make([]int, len(k.paradigmPrefixes))
 	for i := range totalCounts {
 		totalCounts[i] = 1
@@ -203,13 +223,16 @@ func (k *KnownSuffixAnalyzer) Parse(word, wordLower string, seen map[string]stru
 		if !strings.HasPrefix(wordLower, pref.Prefix) {
 			continue
 		}
+		if pref.ID >= len(dict.PredictionSuffixes()) {
+			continue
+		}
 		suffixDawg := dict.PredictionSuffixes()[pref.ID]
 		for _, split := range k.predictionSplits {
-			if split > len(wordLower) {
+			if split > len(runes) {
 				continue
 			}
-			wordStart := wordLower[:len(wordLower)-split]
-			wordEnd := wordLower[len(wordLower)-split:]
+			wordStart := string(runes[:len(runes)-split])
+			wordEnd := string(runes[len(runes)-split:])
 			items := suffixDawg.SimilarItems(wordEnd, subs)
 			for suffix, parses := range items {
 				fixedWord := wordStart + suffix
@@ -251,11 +274,12 @@ func (k *KnownSuffixAnalyzer) Parse(word, wordLower string, seen map[string]stru
 }
 
 func (k *KnownSuffixAnalyzer) Tag(word, wordLower string, seen map[string]struct{}) []tagset.Tag {
-	if len(word) < k.MinWordLength {
+	if utf8.RuneCountInString(word) < k.MinWordLength {
 		return nil
 	}
 	dict, _ := k.Dict.(*dict.Dictionary)
 	subs := k.Morph.CharSubstitutes()
+	runes := []rune(wordLower)
 	type tmp struct {
 		cnt int
 		tag tagset.Tag
@@ -265,12 +289,15 @@ func (k *KnownSuffixAnalyzer) Tag(word, wordLower string, seen map[string]struct
 		if !strings.HasPrefix(wordLower, pref.Prefix) {
 			continue
 		}
+		if pref.ID >= len(dict.PredictionSuffixes()) {
+			continue
+		}
 		suffixDawg := dict.PredictionSuffixes()[pref.ID]
 		for _, split := range k.predictionSplits {
-			if split > len(wordLower) {
+			if split > len(runes) {
 				continue
 			}
-			end := wordLower[len(wordLower)-split:]
+			end := string(runes[len(runes)-split:])
 			items := suffixDawg.SimilarItems(end, subs)
 			found := false
 			for _, parses := range items {
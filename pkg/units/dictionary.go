@@ -22,19 +22,29 @@ func (d *DictionaryAnalyzer) Parse(word, wordLower string, seenParses map[string
 		subs = d.Morph.CharSubstitutes()
 	}
 	res := []analysis.Parse{}
-	items := dictionary.Words().SimilarItems(wordLower, subs)
+	items := dictionary.SimilarItems(wordLower, subs)
 	for _, it := range items {
 		for _, wf := range it.Forms {
 			tag := dictionary.BuildTagInfo(int(wf.ParadigmID), int(wf.FormIndex))
 			normal := dictionary.BuildNormalForm(int(wf.ParadigmID), int(wf.FormIndex), it.Word)
-			method := dictMethod{Analyzer: d, Word: it.Word, ParaID: int(wf.ParadigmID), Index: int(wf.FormIndex)}
-			parse := analysis.NewParse(it.Word, &tag, normal, 1.0, []interface{}{method})
-			AddParseIfNotSeen(parse, &res, seenParses)
+			kw := dict.KnownWord{Word: it.Word, Tag: tag, NormalForm: normal, ParadigmID: wf.ParadigmID, Index: wf.FormIndex}
+			AddParseIfNotSeen(d.KnownWordParse(kw), &res, seenParses)
 		}
 	}
 	return res
 }
 
+// KnownWordParse returns the parse of a dictionary word form, as Parse
+// returns it.
+func (d *DictionaryAnalyzer) KnownWordParse(w dict.KnownWord) analysis.Parse {
+	method := dictMethod{Analyzer: d, Word: w.Word, ParaID: int(w.ParadigmID), Index: int(w.Index)}
+	parse := analysis.NewParse(w.Word, &w.Tag, w.NormalForm, 1.0, []interface{}{method})
+	if dictionary, ok := d.Dict.(*dict.Dictionary); ok {
+		parse.LemmaID = dictionary.LemmaID(int(w.ParadigmID), w.NormalForm)
+	}
+	return parse
+}
+
 // Tag a word using the dictionary.
 func (d *DictionaryAnalyzer) Tag(word, wordLower string, seenTags map[string]struct{}) []tagset.Tag {
 	dictionary, ok := d.Dict.(*dict.Dictionary)
@@ -46,7 +56,7 @@ func (d *DictionaryAnalyzer) Tag(word, wordLower string, seenTags map[string]str
 		subs = d.Morph.CharSubstitutes()
 	}
 	res := []tagset.Tag{}
-	values := dictionary.Words().SimilarItemValues(wordLower, subs)
+	values := dictionary.SimilarItemValues(wordLower, subs)
 	for _, forms := range values {
 		for _, wf := range forms {
 			tag := dictionary.BuildTagInfo(int(wf.ParadigmID), int(wf.FormIndex))
@@ -77,6 +87,7 @@ func (d *DictionaryAnalyzer) GetLexeme(p analysis.Parse) []analysis.Parse {
 		word := form.Prefix + stem + form.Suffix
 		newStack := d.fixStack(p.MethodsStack, word, paraID, i)
 		parse := analysis.NewParse(word, &form.Tag, p.NormalForm, 1.0, newStack)
+		parse.LemmaID = p.LemmaID
 		res = append(res, parse)
 	}
 	return res
@@ -95,7 +106,9 @@ func (d *DictionaryAnalyzer) Normalized(p analysis.Parse) analysis.Parse {
 	normal := p.NormalForm
 	tag := dictionary.BuildTagInfo(paraID, 0)
 	newStack := d.fixStack(p.MethodsStack, normal, paraID, 0)
-	return analysis.NewParse(normal, &tag, normal, 1.0, newStack)
+	res := analysis.NewParse(normal, &tag, normal, 1.0, newStack)
+	res.LemmaID = p.LemmaID
+	return res
 }
 
 type dictMethod struct {
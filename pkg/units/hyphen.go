@@ -27,6 +27,12 @@ type hyphenParticleMethod struct {
 
 func (m hyphenParticleMethod) Unit() AnalyzerUnit { return m.Analyzer }
 
+// Clone returns a copy of analyzer.
+func (h *HyphenSeparatedParticleAnalyzer) Clone() AnalyzerUnit {
+	cloned := *h
+	return &cloned
+}
+
 func (h *HyphenSeparatedParticleAnalyzer) Parse(word, wordLower string, seen map[string]struct{}) []analysis.Parse {
 	res := []analysis.Parse{}
 	for _, part := range h.Particles {
@@ -130,6 +136,12 @@ func NewHyphenAdverbAnalyzer() *HyphenAdverbAnalyzer {
 	return &HyphenAdverbAnalyzer{ScoreMultiplier: 0.7}
 }
 
+// Clone returns a copy of analyzer.
+func (h *HyphenAdverbAnalyzer) Clone() AnalyzerUnit {
+	cloned := *h
+	return &cloned
+}
+
 func (h *HyphenAdverbAnalyzer) Init(morph Analyzer) {
 	h.BaseAnalyzerUnit.Init(morph)
 	t, _ := tagset.New("ADVB")
@@ -191,6 +203,12 @@ func NewHyphenatedWordsAnalyzer(skip []string) *HyphenatedWordsAnalyzer {
 	return &HyphenatedWordsAnalyzer{SkipPrefixes: skip, ScoreMultiplier: 0.75}
 }
 
+// Clone returns a copy of analyzer.
+func (h *HyphenatedWordsAnalyzer) Clone() AnalyzerUnit {
+	cloned := *h
+	return &cloned
+}
+
 func (h *HyphenatedWordsAnalyzer) Init(morph Analyzer) {
 	h.BaseAnalyzerUnit.Init(morph)
 	h.matcher = dawg.NewPrefixMatcher(h.SkipPrefixes)
@@ -25,6 +25,12 @@ func NewPunctuationAnalyzer() *PunctuationAnalyzer {
 	return &PunctuationAnalyzer{score: 0.9}
 }
 
+// Clone returns a copy of analyzer.
+func (a *PunctuationAnalyzer) Clone() AnalyzerUnit {
+	cloned := *a
+	return &cloned
+}
+
 // Init registers grammemes and builds tag.
 func (a *PunctuationAnalyzer) Init(morph Analyzer) {
 	a.BaseAnalyzerUnit.Init(morph)
@@ -68,6 +74,12 @@ type LatinAnalyzer struct {
 
 func NewLatinAnalyzer() *LatinAnalyzer { return &LatinAnalyzer{score: 0.9} }
 
+// Clone returns a copy of analyzer.
+func (a *LatinAnalyzer) Clone() AnalyzerUnit {
+	cloned := *a
+	return &cloned
+}
+
 func (a *LatinAnalyzer) Init(morph Analyzer) {
 	a.BaseAnalyzerUnit.Init(morph)
 	tagset.AddGrammemeToKnown("LATN", "ЛАТ", false)
@@ -105,6 +117,12 @@ type NumberAnalyzer struct {
 
 func NewNumberAnalyzer() *NumberAnalyzer { return &NumberAnalyzer{score: 0.9} }
 
+// Clone returns a copy of analyzer.
+func (a *NumberAnalyzer) Clone() AnalyzerUnit {
+	cloned := *a
+	return &cloned
+}
+
 func (a *NumberAnalyzer) Init(morph Analyzer) {
 	a.BaseAnalyzerUnit.Init(morph)
 	pairs := [][2]string{{"NUMB", "ЧИСЛО"}, {"intg", "цел"}, {"real", "вещ"}}
@@ -160,6 +178,12 @@ type RomanNumberAnalyzer struct {
 
 func NewRomanNumberAnalyzer() *RomanNumberAnalyzer { return &RomanNumberAnalyzer{score: 0.9} }
 
+// Clone returns a copy of analyzer.
+func (a *RomanNumberAnalyzer) Clone() AnalyzerUnit {
+	cloned := *a
+	return &cloned
+}
+
 func (a *RomanNumberAnalyzer) Init(morph Analyzer) {
 	a.BaseAnalyzerUnit.Init(morph)
 	tagset.AddGrammemeToKnown("ROMN", "РИМ", false)
@@ -21,6 +21,12 @@ func NewUnknAnalyzer() *UnknAnalyzer {
 	return &UnknAnalyzer{score: 1.0}
 }
 
+// Clone returns a copy of analyzer.
+func (u *UnknAnalyzer) Clone() AnalyzerUnit {
+	cloned := *u
+	return &cloned
+}
+
 // Init prepares analyzer and registers grammeme.
 func (u *UnknAnalyzer) Init(morph Analyzer) {
 	u.BaseAnalyzerUnit.Init(morph)
@@ -2,7 +2,8 @@ package utils
 
 import "container/list"
 
-// LRUCache is a simple fixed-size LRU cache.
+// LRUCache is a simple fixed-size LRU cache. It is not safe for concurrent
+// use.
 type LRUCache[K comparable, V any] struct {
 	capacity int
 	items    map[K]*list.Element
@@ -52,6 +53,15 @@ func (c *LRUCache[K, V]) Set(key K, value V) {
 	}
 }
 
+// Len returns the number of cached items.
+func (c *LRUCache[K, V]) Len() int { return c.order.Len() }
+
+// Clear removes all items.
+func (c *LRUCache[K, V]) Clear() {
+	clear(c.items)
+	c.order.Init()
+}
+
 // MemoizedWithSingleArgument returns memoized version of function using provided cache map.
 func MemoizedWithSingleArgument[K comparable, V any](cache map[K]V, fn func(K) V) func(K) V {
 	return func(arg K) V {
@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// LongestCommonSubstring returns the longest common substring for all strings
+// in data. Strings are compared as bytes, but the result never starts or ends
+// in the middle of a UTF-8 sequence; of several longest substrings the one
+// starting first in data[0] is returned.
+//
+// It builds a suffix automaton of data[0] and matches other strings against
+// it, so the running time is linear in the total length of data. Matching
+// gives an upper bound of the result length; when the bound drops, the few
+// substrings of that length are checked against the remaining strings
+// directly, which usually ends the search early.
+func LongestCommonSubstring(data []string) string {
+	if len(data) == 0 {
+		return ""
+	}
+	first := data[0]
+	if len(data) == 1 || first == "" {
+		return first
+	}
+	// the first string is often the stem itself
+	if containedInAll(first, data[1:]) {
+		return first
+	}
+	sa := newSuffixAutomaton(first)
+	// best[v] is the length of the longest suffix of strings of state v
+	// found in every string checked so far; alive lists states with
+	// best[v] > 0.
+	best := make([]int32, len(sa.states))
+	alive := make([]int32, 0, len(sa.states)-1)
+	for v := 1; v < len(sa.states); v++ {
+		best[v] = sa.states[v].len
+		alive = append(alive, int32(v))
+	}
+	// cur[v] is the length of the longest match of the current string
+	// ending in state v; touched lists states with cur[v] > 0.
+	cur := make([]int32, len(sa.states))
+	var touched []int32
+	bound, rounds := int32(len(first)), 0
+	for i, s := range data[1:] {
+		touched = sa.match(s, cur, touched[:0])
+		// a match ending in a state also ends in its suffix link states
+		for j := 0; j < len(touched); j++ {
+			link := sa.states[touched[j]].link
+			if link > 0 && cur[link] < sa.states[link].len {
+				if cur[link] == 0 {
+					touched = append(touched, link)
+				}
+				cur[link] = sa.states[link].len
+			}
+		}
+		n, maxLen := 0, int32(0)
+		for _, v := range alive {
+			if best[v] = min(best[v], cur[v]); best[v] > 0 {
+				alive[n] = v
+				n++
+				maxLen = max(maxLen, sa.aligned(first, v, best[v]))
+			}
+		}
+		alive = alive[:n]
+		for _, v := range touched {
+			cur[v] = 0
+		}
+		if maxLen == 0 {
+			return ""
+		}
+		if maxLen < bound && rounds < maxCheckRounds {
+			bound = maxLen
+			rounds++
+			if res, ok := sa.checkLongest(first, alive, best, maxLen, data[i+2:]); ok {
+				return res
+			}
+		}
+	}
+	var maxLen, start int32
+	for _, v := range alive {
+		l := sa.aligned(first, v, best[v])
+		s := sa.states[v].firstPos - l + 1
+		if l > maxLen || l == maxLen && s < start {
+			maxLen, start = l, s
+		}
+	}
+	return first[start : start+maxLen]
+}
+
+// Limits of direct checks in LongestCommonSubstring, which keep its running
+// time linear.
+const (
+	maxCheckRounds     = 8
+	maxCheckCandidates = 4
+)
+
+// checkLongest looks for the first substring of first of length l, common to
+// the strings matched so far and not splitting runes, that all strings in rest
+// contain. As l is the upper bound of the result length, such a substring is
+// the result.
+func (sa *suffixAutomaton) checkLongest(first string, alive, best []int32, l int32, rest []string) (string, bool) {
+	var starts []int32
+	for _, v := range alive {
+		if sa.aligned(first, v, best[v]) == l {
+			if len(starts) == maxCheckCandidates {
+				return "", false
+			}
+			starts = append(starts, sa.states[v].firstPos-l+1)
+		}
+	}
+	slices.Sort(starts)
+	for _, start := range starts {
+		if cand := first[start : start+l]; containedInAll(cand, rest) {
+			return cand, true
+		}
+	}
+	return "", false
+}
+
+// aligned returns the length of the longest common suffix, no longer than l,
+// of strings of state v which doesn't split UTF-8 sequences of first. It is 0
+// when the strings of v end in the middle of a sequence.
+func (sa *suffixAutomaton) aligned(first string, v, l int32) int32 {
+	end := sa.states[v].firstPos + 1
+	if int(end) < len(first) && !utf8.RuneStart(first[end]) {
+		return 0
+	}
+	for l > 0 && !utf8.RuneStart(first[end-l]) {
+		l--
+	}
+	return l
+}
+
+func containedInAll(substr string, data []string) bool {
+	for _, s := range data {
+		if !strings.Contains(s, substr) {
+			return false
+		}
+	}
+	return true
+}
+
+// suffixAutomaton is the minimal automaton recognizing suffixes of a byte
+// string. Bytes are renumbered densely by their first occurrence in the
+// string, so transitions fit a small table.
+type suffixAutomaton struct {
+	states []samState
+	// code maps bytes to columns of trans, -1 for bytes not in the string
+	code  [256]int16
+	sigma int
+	// trans[v*sigma+c] is the transition of state v by column c or -1
+	trans []int32
+}
+
+type samState struct {
+	len  int32
+	link int32
+	// firstPos is the end position of the first occurrence of the state's
+	// strings.
+	firstPos int32
+}
+
+func newSuffixAutomaton(s string) *suffixAutomaton {
+	sa := &suffixAutomaton{states: make([]samState, 0, 2*len(s))}
+	for i := range sa.code {
+		sa.code[i] = -1
+	}
+	for i := 0; i < len(s); i++ {
+		if sa.code[s[i]] < 0 {
+			sa.code[s[i]] = int16(sa.sigma)
+			sa.sigma++
+		}
+	}
+	sa.trans = make([]int32, 0, 2*len(s)*sa.sigma)
+	last := sa.addState(samState{link: -1})
+	for i := 0; i < len(s); i++ {
+		last = sa.extend(last, int(sa.code[s[i]]), int32(i))
+	}
+	return sa
+}
+
+func (sa *suffixAutomaton) addState(st samState) int32 {
+	sa.states = append(sa.states, st)
+	for i := 0; i < sa.sigma; i++ {
+		sa.trans = append(sa.trans, -1)
+	}
+	return int32(len(sa.states) - 1)
+}
+
+// extend appends the byte of column c at position pos to the automaton
+// whose whole string ends in state last, returning the new last state.
+func (sa *suffixAutomaton) extend(last int32, c int, pos int32) int32 {
+	sigma := int32(sa.sigma)
+	cur := sa.addState(samState{len: sa.states[last].len + 1, firstPos: pos})
+	p := last
+	for p >= 0 && sa.trans[p*sigma+int32(c)] < 0 {
+		sa.trans[p*sigma+int32(c)] = cur
+		p = sa.states[p].link
+	}
+	if p < 0 {
+		sa.states[cur].link = 0
+		return cur
+	}
+	q := sa.trans[p*sigma+int32(c)]
+	if sa.states[p].len+1 == sa.states[q].len {
+		sa.states[cur].link = q
+		return cur
+	}
+	clone := sa.addState(samState{
+		len:      sa.states[p].len + 1,
+		link:     sa.states[q].link,
+		firstPos: sa.states[q].firstPos,
+	})
+	copy(sa.trans[clone*sigma:(clone+1)*sigma], sa.trans[q*sigma:(q+1)*sigma])
+	for p >= 0 && sa.trans[p*sigma+int32(c)] == q {
+		sa.trans[p*sigma+int32(c)] = clone
+		p = sa.states[p].link
+	}
+	sa.states[q].link = clone
+	sa.states[cur].link = clone
+	return cur
+}
+
+// match runs s through the automaton, storing in cur the length of the
+// longest match ending in each state. States which got a match are appended
+// to touched.
+func (sa *suffixAutomaton) match(s string, cur, touched []int32) []int32 {
+	states, trans, sigma := sa.states, sa.trans, int32(sa.sigma)
+	v, l := int32(0), int32(0)
+	for i := 0; i < len(s); i++ {
+		c := int32(sa.code[s[i]])
+		if c < 0 {
+			v, l = 0, 0
+			continue
+		}
+		to := trans[v*sigma+c]
+		for to < 0 && v > 0 {
+			v = states[v].link
+			l = states[v].len
+			to = trans[v*sigma+c]
+		}
+		if to < 0 {
+			continue
+		}
+		v = to
+		l++
+		if cur[v] == 0 {
+			touched = append(touched, v)
+		}
+		if l > cur[v] {
+			cur[v] = l
+		}
+	}
+	return touched
+}
@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// longestCommonSubstringNaive is a quadratic implementation of
+// LongestCommonSubstring, used as a reference.
+func longestCommonSubstringNaive(data []string) string {
+	if len(data) == 0 {
+		return ""
+	}
+	if len(data) == 1 {
+		return data[0]
+	}
+	first := data[0]
+	substr := ""
+	for i := 0; i < len(first); i++ {
+		if !utf8.RuneStart(first[i]) {
+			continue
+		}
+		for j := i + 1; j <= len(first); j++ {
+			if j < len(first) && !utf8.RuneStart(first[j]) {
+				continue
+			}
+			candidate := first[i:j]
+			if len(candidate) <= len(substr) {
+				continue
+			}
+			ok := true
+			for _, s := range data[1:] {
+				if !strings.Contains(s, candidate) {
+					ok = false
+					break
+				}
+			}
+			if ok {
+				substr = candidate
+			}
+		}
+	}
+	return substr
+}
+
+func TestLongestCommonSubstring(t *testing.T) {
+	tests := []struct {
+		data []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"ёж"}, "ёж"},
+		{[]string{"", "abc"}, ""},
+		{[]string{"abc", "def"}, ""},
+		{[]string{"ёж", "ежа", "ежу"}, "ж"},
+		{[]string{"читать", "читаю", "прочитал"}, "чита"},
+		// equal lengths: the first one in data[0] wins
+		{[]string{"abxcd", "cdab"}, "ab"},
+		// "б" and "в" share the first byte, which is not a match
+		{[]string{"аб", "ав"}, "а"},
+		{[]string{"кращий", "кращого", "найкращого"}, "кращ"},
+		{[]string{"бя", "вя"}, "я"},
+	}
+	for _, tt := range tests {
+		if got := LongestCommonSubstring(tt.data); got != tt.want {
+			t.Errorf("LongestCommonSubstring(%q) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
+
+// longestCommonSubstringBytes is the quadratic byte-wise implementation
+// LongestCommonSubstring replaced. On ASCII strings the results must match.
+func longestCommonSubstringBytes(data []string) string {
+	if len(data) == 0 {
+		return ""
+	}
+	if len(data) == 1 {
+		return data[0]
+	}
+	first := data[0]
+	substr := ""
+	for i := 0; i < len(first); i++ {
+		for j := i + 1; j <= len(first); j++ {
+			candidate := first[i:j]
+			if len(candidate) <= len(substr) {
+				continue
+			}
+			ok := true
+			for _, s := range data[1:] {
+				if !strings.Contains(s, candidate) {
+					ok = false
+					break
+				}
+			}
+			if ok {
+				substr = candidate
+			}
+		}
+	}
+	return substr
+}
+
+// checkLongestCommonSubstring compares LongestCommonSubstring with ref on
+// random strings over small alphabets, so that long common substrings and
+// ties are frequent.
+func checkLongestCommonSubstring(t *testing.T, alphabets [][]string, ref func([]string) string) {
+	t.Helper()
+	rng := rand.New(rand.NewPCG(1, 2))
+	randomString := func(alphabet []string, n int) string {
+		var b strings.Builder
+		for i := 0; i < n; i++ {
+			b.WriteString(alphabet[rng.IntN(len(alphabet))])
+		}
+		return b.String()
+	}
+	for i := 0; i < 20000; i++ {
+		alphabet := alphabets[rng.IntN(len(alphabets))]
+		stem := randomString(alphabet, rng.IntN(8))
+		data := make([]string, 1+rng.IntN(12))
+		for j := range data {
+			data[j] = randomString(alphabet, rng.IntN(5)) + stem + randomString(alphabet, rng.IntN(5))
+		}
+		got, want := LongestCommonSubstring(data), ref(data)
+		if got != want {
+			t.Fatalf("LongestCommonSubstring(%q) = %q, want %q", data, got, want)
+		}
+		for _, s := range data {
+			if !strings.Contains(s, got) {
+				t.Fatalf("LongestCommonSubstring(%q) = %q is not a substring of %q", data, got, s)
+			}
+		}
+	}
+}
+
+func TestLongestCommonSubstringProperties(t *testing.T) {
+	checkLongestCommonSubstring(t, [][]string{
+		{"a", "b"},
+		{"а", "б", "в", "ё"},
+		{"о", "м", "й", "ю", "х", "a"},
+	}, longestCommonSubstringNaive)
+}
+
+// TestLongestCommonSubstringBytes checks that results on ASCII strings are
+// the ones of the previous implementation.
+func TestLongestCommonSubstringBytes(t *testing.T) {
+	checkLongestCommonSubstring(t, [][]string{
+		{"a", "b"},
+		{"a", "b", "c", "d"},
+	}, longestCommonSubstringBytes)
+}
+
+func BenchmarkLongestCommonSubstring(b *testing.B) {
+	forms := []string{
+		"читать", "читаю", "читаешь", "читает", "читаем", "читаете", "читают",
+		"читал", "читала", "читало", "читали", "читай", "читайте", "читая",
+		"читающий", "читающего", "читающему", "читающим", "читающем",
+		"читающая", "читающей", "читающую", "читающее", "читающие", "читающих",
+		"читавший", "читавшего", "читавшему", "читавшим", "читавшем", "читав",
+		"читаемый", "читаемого", "читаемому", "читаемым", "читаемом", "читаем",
+	}
+	for i := 0; i < b.N; i++ {
+		LongestCommonSubstring(forms)
+	}
+}
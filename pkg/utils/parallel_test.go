@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestParallelFor(t *testing.T) {
+	tests := []struct{ n, workers int }{
+		{0, 0}, {0, 4}, {1, 0}, {3, 8}, {100, 1}, {100, 4}, {1000, 0},
+	}
+	for _, tt := range tests {
+		visits := make([]atomic.Int32, tt.n)
+		ParallelFor(tt.n, tt.workers, func(i int) {
+			visits[i].Add(1)
+		})
+		for i := range visits {
+			if got := visits[i].Load(); got != 1 {
+				t.Errorf("n=%d workers=%d: index %d visited %d times", tt.n, tt.workers, i, got)
+			}
+		}
+	}
+}
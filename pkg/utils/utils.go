@@ -32,37 +32,6 @@ func CombinationsOfAllLengths(items []string) [][]string {
 	return res
 }
 
-// LongestCommonSubstring returns the longest common substring for all strings in data.
-func LongestCommonSubstring(data []string) string {
-	if len(data) == 0 {
-		return ""
-	}
-	if len(data) == 1 {
-		return data[0]
-	}
-	first := data[0]
-	substr := ""
-	for i := 0; i < len(first); i++ {
-		for j := i + 1; j <= len(first); j++ {
-			candidate := first[i:j]
-			if len(candidate) <= len(substr) {
-				continue
-			}
-			ok := true
-			for _, s := range data[1:] {
-				if !strings.Contains(s, candidate) {
-					ok = false
-					break
-				}
-			}
-			if ok {
-				substr = candidate
-			}
-		}
-	}
-	return substr
-}
-
 // LargestElements returns elements that have one of the top-n key values.
 func LargestElements[T any](iter []T, key func(T) float64, n int) []T {
 	if n <= 0 {